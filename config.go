@@ -15,11 +15,18 @@
 package generator
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/conduitio/conduit-commons/opencdc"
 	"github.com/conduitio/conduit-connector-generator/internal"
@@ -27,29 +34,148 @@ import (
 	"golang.org/x/time/rate"
 )
 
+const (
+	EndOfStreamBlock = "block"
+	EndOfStreamError = "error"
+)
+
+const (
+	ModeGenerate = "generate"
+	ModePool     = "pool"
+)
+
 const (
 	FormatTypeRaw        = "raw"
 	FormatTypeStructured = "structured"
 	FormatTypeFile       = "file"
+	FormatTypeAvro       = "avro"
+	FormatTypeSQL        = "sql"
+	FormatTypeProtobuf   = "protobuf"
+	FormatTypeSample     = "sample"
 )
 
 type Config struct {
 	sdk.DefaultSourceMiddleware
 
-	Burst BurstConfig `json:"burst"`
+	Burst      BurstConfig      `json:"burst"`
+	Trace      TraceConfig      `json:"trace"`
+	Faults     FaultsConfig     `json:"faults"`
+	Duplicates DuplicatesConfig `json:"duplicates"`
+	Clock      ClockConfig      `json:"clock"`
+	Acks       AcksConfig       `json:"acks"`
 	// Number of records to be generated (0 means infinite).
 	RecordCount int `json:"recordCount" validate:"gt=-1"`
+	// The seed of the random values in generated records and of random
+	// timing, duplicates and faults (0 means a random seed). Connectors with
+	// the same seed and configuration generate the same records, if the
+	// creation times are reproducible with `clock.step`. After a restart
+	// the random values are derived from the seed and the position.
+	Seed int64 `json:"seed"`
+	// The maximum duration for which records are generated after the
+	// connector is opened (0 means infinite).
+	MaxDuration time.Duration `json:"maxDuration"`
+	// The time at which the generator starts generating records, in RFC 3339
+	// format.
+	StartAt string `json:"startAt"`
+	// The time after which no more records are generated, in RFC 3339 format.
+	StopAt string `json:"stopAt"`
+	// Comma separated list of daily time windows in UTC in which records are
+	// generated, e.g. `09:00-12:00,13:00-17:00`. Windows can span midnight
+	// (e.g. `22:00-02:00`). Outside of the windows the generator waits for the
+	// next window.
+	Windows []string `json:"windows"`
+	// The behavior after the last record was generated because `recordCount`,
+	// `maxDuration`, `stopAt` or the end of the trace was reached (block,
+	// error). With `block` the connector stops producing records and waits
+	// until the pipeline is stopped, the pipeline keeps running. With `error`
+	// it returns an end of stream error. Conduit handles it like any other
+	// read error, so the pipeline stops with that error and ends in a failed
+	// (degraded) state.
+	EndOfStream string `json:"endOfStream" default:"block" validate:"inclusion=block|error"`
+	// The maximum number of records that were read but not acknowledged yet
+	// (0 means no limit). Once the limit is reached, reading blocks until
+	// records are acknowledged, so the throughput follows the capacity of
+	// the pipeline.
+	MaxInFlight int `json:"maxInFlight" validate:"gt=-1"`
+	// How records are produced (generate, pool). With `generate` a new record
+	// is generated each time. With `pool` the generator pre-generates
+	// `pool.size` records per collection when the connector is opened and
+	// cycles through them with fresh positions, so the generator is not the
+	// bottleneck when measuring the throughput of a pipeline. Structured
+	// keys and payloads are copied for each record, raw data is shared. When
+	// the connector is opened from a position, the pool is generated from
+	// the state in the position.
+	Mode string `json:"mode" default:"generate" validate:"inclusion=generate|pool"`
+	// The number of records pre-generated per collection (only applicable if
+	// `mode` is `pool`).
+	PoolSize int `json:"pool.size" default:"1000" validate:"gt=0"`
 	// The time it takes to 'read' a record.
 	// Deprecated: use `rate` instead.
 	ReadTime time.Duration `json:"readTime"`
 	// The maximum rate in records per second, at which records are generated (0
 	// means no rate limit).
-	Rate float64 `json:"rate"`
+	Rate float64 `json:"rate" default:"0"`
+	// The shape of the rate over time (none, ramp, step, sine, diurnal). The
+	// `ramp` profile increases the rate linearly from `rate.min` to `rate`
+	// during `rate.period`. The `step` profile holds each rate in `rate.steps`
+	// for `rate.period`. The `sine` profile oscillates between `rate.min` and
+	// `rate` with the period `rate.period`. The `diurnal` profile follows a
+	// daily curve between `rate.min` and `rate`, compressed into
+	// `rate.period` (e.g. `10m` for a day in 10 minutes).
+	RateProfile string `json:"rate.profile" default:"none" validate:"inclusion=none|ramp|step|sine|diurnal"`
+	// The lowest rate in records per second of the rate profile.
+	RateMin float64 `json:"rate.min"`
+	// The period of the rate profile.
+	RatePeriod time.Duration `json:"rate.period"`
+	// Comma separated list of rates in records per second used by the `step`
+	// rate profile.
+	RateSteps []float64 `json:"rate.steps"`
+	// The model of the time between generated records (constant, poisson,
+	// uniform). With `constant` records are evenly spaced, with `poisson` the
+	// records arrive as a Poisson process and with `uniform` the time between
+	// records deviates randomly from the mean by up to `rate.jitter`. All
+	// models keep the configured average rate, records that are read late
+	// are emitted right away to catch up (by at most 100 records).
+	RateArrival string `json:"rate.arrival" default:"constant" validate:"inclusion=constant|poisson|uniform"`
+	// The maximum deviation of the time between records from the mean, as a
+	// fraction of the mean (only applicable if `rate.arrival` is `uniform`).
+	RateJitter float64 `json:"rate.jitter" default:"0.5"`
 
 	// Configuration for default collection (i.e. records without a collection).
 	// Kept for backwards compatibility.
 	CollectionConfig
 	Collections map[string]CollectionConfig `json:"collections"`
+
+	// Phases of the generated scenario, executed sequentially in the order of
+	// their names (numeric names are ordered numerically, e.g.
+	// `phases.1.*`, `phases.2.*`, `phases.10.*`). Each phase can override the
+	// rate, bursts, operations and collections. After the last phase ended,
+	// no more records are generated.
+	Phases map[string]PhaseConfig `json:"phases"`
+}
+
+type PhaseConfig struct {
+	// The duration of the phase. The phase ends when the duration passed or
+	// `recordCount` records were generated, whichever comes first. A phase
+	// without a duration and record count never ends.
+	Duration time.Duration `json:"duration"`
+	// Number of records generated in the phase (0 means infinite).
+	RecordCount int `json:"recordCount" validate:"gt=-1"`
+	// The maximum rate in records per second in the phase (0 means no rate
+	// limit). If not set, `rate` is used.
+	Rate *float64 `json:"rate"`
+	// The time the generator "sleeps" between bursts in the phase. If not
+	// set, `burst.sleepTime` is used.
+	BurstSleepTime *time.Duration `json:"burst.sleepTime"`
+	// The amount of time the generator is generating records in a burst in
+	// the phase. If not set, `burst.generateTime` is used.
+	BurstGenerateTime *time.Duration `json:"burst.generateTime"`
+	// Comma separated list of record operations generated in all collections
+	// in the phase. If not set, the operations of each collection are used.
+	Operations []string `json:"operations"`
+	// Comma separated list of collections generating records in the phase.
+	// If not set, all collections generate records.
+	Collections []string `json:"collections"`
 }
 
 type BurstConfig struct {
@@ -60,21 +186,232 @@ type BurstConfig struct {
 	GenerateTime time.Duration `json:"generateTime" default:"1s"`
 }
 
+type FaultsConfig struct {
+	// Number of records after which reading a record fails once (0 means
+	// never).
+	AfterRecords int `json:"afterRecords" validate:"gt=-1"`
+	// The probability with which reading a record fails (between 0 and 1).
+	Probability float64 `json:"probability"`
+	// Comma separated list of durations after opening the connector at which
+	// reading a record fails once, e.g. `30s,5m`.
+	At []time.Duration `json:"at"`
+	// Whether opening the connector fails.
+	Open bool `json:"open"`
+	// Whether tearing down the connector fails.
+	Teardown bool `json:"teardown"`
+	// The kind of injected read errors (transient, permanent). Transient
+	// errors are retried by the SDK after a backoff, so the pipeline keeps
+	// running. Permanent errors stop the pipeline and all subsequent reads
+	// fail, also after a restart. Faults after `faults.afterRecords` are not
+	// injected again after a restart.
+	Kind string `json:"kind" default:"transient" validate:"inclusion=transient|permanent"`
+}
+
+type DuplicatesConfig struct {
+	// The probability with which a generated record is emitted again
+	// (between 0 and 1). Duplicates have the same key and payload as the
+	// original record and the metadata field `generator.duplicate` set.
+	Probability float64 `json:"probability"`
+	// The time after which a duplicate is emitted, following the original
+	// record.
+	Delay time.Duration `json:"delay"`
+	// Whether duplicates have the same position as the original record.
+	// Otherwise they get a new position.
+	SamePosition bool `json:"samePosition"`
+	// Number of the last generated records that are emitted again when the
+	// connector is opened from a position. The records are stored in the
+	// position, so keep this number small.
+	Replay int `json:"replay" validate:"gt=-1"`
+	// The maximum total size of the records stored in the position for
+	// replaying, e.g. `64KB`. The oldest records are dropped once the size
+	// is exceeded, so fewer than `duplicates.replay` records may be replayed.
+	// An empty value doesn't limit the size.
+	ReplayMaxSize string `json:"replayMaxSize" default:"64KB"`
+}
+
+type ClockConfig struct {
+	// The time at which the simulated clock starts, in RFC 3339 format. The
+	// simulated clock provides the creation time of records and the values
+	// of time fields. If not set, the wall clock is used.
+	Start string `json:"start"`
+	// The factor by which the simulated clock runs faster than the wall
+	// clock, e.g. `525600` simulates a year in an hour.
+	Speed float64 `json:"speed" default:"1"`
+	// The duration by which the simulated clock advances with every
+	// generated record, instead of following the wall clock. This makes the
+	// generated timestamps reproducible.
+	Step time.Duration `json:"step"`
+}
+
+type AcksConfig struct {
+	// Whether acknowledgments of unknown positions, duplicate or out of
+	// order acknowledgments and positions not acknowledged at teardown
+	// return an error, which stops the pipeline. Violations are always
+	// logged.
+	FailOnViolation bool `json:"failOnViolation"`
+	// The interval in which the ack statistics and the percentiles (p50, p95,
+	// p99) of the latency between emitting and acknowledging records are
+	// logged (0 means only at teardown).
+	LogInterval time.Duration `json:"logInterval" default:"1m"`
+}
+
+type TraceConfig struct {
+	// Path to a trace file that determines when records are generated,
+	// instead of `rate` and `burst`. The file contains one entry per line,
+	// lines can contain comma separated columns of which the last one is
+	// used (e.g. `timestamp,count`).
+	Path string `json:"path"`
+	// The format of the trace file (timestamps, counts). With `timestamps`
+	// each entry is the time of a record, either in RFC 3339 format or as a
+	// Unix timestamp in seconds or milliseconds. With `counts` each entry is
+	// the number of records in a second.
+	Format string `json:"format" default:"timestamps" validate:"inclusion=timestamps|counts"`
+	// The factor by which the trace is sped up, e.g. `60` replays an hour of
+	// traffic in a minute.
+	Speed float64 `json:"speed" default:"1"`
+	// Whether to replay the trace from the start after it ended. If false, no
+	// more records are generated after the trace ended.
+	Loop bool `json:"loop"`
+}
+
 type CollectionConfig struct {
 	// Comma separated list of record operations to generate. Allowed values are
 	// "create", "update", "delete", "snapshot".
-	Operations []string     `json:"operations" default:"create" validate:"required"`
-	Format     FormatConfig `json:"format"`
+	Operations []string        `json:"operations" default:"create" validate:"required"`
+	Format     FormatConfig    `json:"format"`
+	Evolution  EvolutionConfig `json:"evolution"`
+	Malformed  MalformedConfig `json:"malformed"`
+	EventTime  EventTimeConfig `json:"eventTime"`
+}
+
+type EventTimeConfig struct {
+	// The model of event times used as the creation time of records and in
+	// time fields (monotonic, bounded). With `monotonic` event times never
+	// decrease, with `bounded` they lag behind the processing time by a
+	// random duration of up to `eventTime.maxOutOfOrderness`, so records are
+	// out of order.
+	Model string `json:"model" default:"monotonic" validate:"inclusion=monotonic|bounded"`
+	// The maximum duration by which event times lag behind the processing
+	// time in the `bounded` model.
+	MaxOutOfOrderness time.Duration `json:"maxOutOfOrderness"`
+	// The ratio of late events (between 0 and 1), which lag behind the
+	// processing time by more than `eventTime.maxOutOfOrderness`.
+	LateRatio float64 `json:"lateRatio"`
+	// The distribution of the additional lag of late events: a fixed
+	// duration (e.g. `5m`), a uniform distribution (e.g. `uniform(1m,10m)`)
+	// or an exponential distribution with a mean (e.g. `exponential(5m)`).
+	Lateness string `json:"lateness" default:"1m"`
+	// The offset added to all event times in the collection, simulating a
+	// skewed clock (e.g. `-2s`).
+	Skew time.Duration `json:"skew"`
+}
+
+type MalformedConfig struct {
+	// The probability with which a generated payload is malformed (between 0
+	// and 1). The kind of malformation is stored in the metadata field
+	// `generator.malformed`.
+	Probability float64 `json:"probability"`
+	// Comma separated list of malformations, one of them is chosen randomly
+	// (truncated, wrongTypes, invalidUTF8, oversized, empty). `truncated`
+	// cuts off the serialized payload, `wrongTypes` changes the types of all
+	// fields in structured data and raw JSON objects, `invalidUTF8` inserts
+	// invalid UTF-8 bytes, `oversized` pads the payload to
+	// `malformed.oversizedSize` and `empty` removes all data. `oversized` is
+	// not used by default and has to be listed explicitly.
+	Kinds []string `json:"kinds" default:"truncated,wrongTypes,invalidUTF8,empty"`
+	// The size of oversized payloads, e.g. `16MB`.
+	OversizedSize string `json:"oversizedSize" default:"16MB"`
+}
+
+type EvolutionConfig struct {
+	// Number of records generated in the collection after which the next
+	// schema evolution step is applied (0 means no record limit).
+	Records int `json:"records" validate:"gt=-1"`
+	// The time after which the next schema evolution step is applied (0 means
+	// no time limit). Measured by the simulated clock if `clock.start` is set.
+	Interval time.Duration `json:"interval"`
+	// Comma separated list of schema evolution steps, applied in order. Allowed
+	// steps are "add:<field>:<type>", "drop:<field>", "widen:<field>:<type>"
+	// (int to float, any type to string) and "rename:<field>:<new name>". Only
+	// applicable if the format type is `raw`, `structured` or `sample`.
+	Steps []string `json:"steps"`
 }
 
 type FormatConfig struct {
-	// The format of the generated payload data (raw, structured, file).
-	Type string `json:"type" validate:"inclusion=raw|structured|file"`
+	// The format of the generated payload data (raw, structured, file, avro,
+	// sql, protobuf, sample).
+	Type string `json:"type" validate:"inclusion=raw|structured|file|avro|sql|protobuf|sample"`
 	// The options for the `raw` and `structured` format types. It accepts pairs
-	// of field names and field types, where the type can be one of: `int`, `string`, `time`, `bool`, `duration`.
+	// of field names and field types, where the type can be one of: `int`, `float`, `string`, `time`, `bool`, `duration`.
+	// The type can be followed by parameters in parentheses, e.g.
+	// `int(min=1,max=100,null=0.1)`. Supported parameters are `min` and `max`
+	// (bounds of numbers), `null` (ratio of null values), `values` (values
+	// separated by `|` to choose from) and `cardinality` (number of distinct
+	// values).
 	Options map[string]string `json:"options"`
-	// Path to the input file (only applicable if the format type is `file`).
+	// Path to the input file (only applicable if the format type is `file`,
+	// `avro`, `sql`, `protobuf` or `sample`). For `avro` the file needs to
+	// contain an Avro schema of type record. For `sql` the file needs to
+	// contain one or more CREATE TABLE statements, each table is generated in a
+	// collection named after the table. Integer primary keys are generated
+	// sequentially, updates change and deletes remove existing rows. Foreign
+	// keys can only reference integer primary keys and referenced tables get
+	// rows before the tables referencing them. For `protobuf` the file needs to be a
+	// `.proto` file or a compiled `FileDescriptorSet`. For `sample` the file
+	// needs to contain sample records in JSONL or CSV format, the fields of the
+	// generated structured records are inferred from the samples.
 	FileOptionsPath string `json:"options.path"`
+	// Fully qualified name of the protobuf message type to generate (only
+	// applicable if the format type is `protobuf`). Can be omitted if the file
+	// contains a single message type.
+	ProtobufOptionsMessage string `json:"options.message"`
+	// Whether to emit protobuf messages as binary encoded raw data instead of
+	// structured data (only applicable if the format type is `protobuf`).
+	ProtobufOptionsBinary bool `json:"options.binary"`
+	// Path to a file the settings inferred from the samples are written to
+	// (only applicable if the format type is `sample`).
+	SampleOptionsOutput string `json:"options.output"`
+	// The encoding of the generated data (only applicable if the format type
+	// is `raw`). With `xml` the field names need to be valid XML element
+	// names, i.e. start with a letter or underscore and only contain letters,
+	// digits, hyphens, underscores and periods. With `avro` they need to be
+	// valid Avro names, i.e. start with an ASCII letter or underscore and only
+	// contain ASCII letters, digits and underscores.
+	Encoding string `json:"encoding" default:"json" validate:"inclusion=json|csv|xml|msgpack|cbor|avro"`
+	// The character separating values in CSV encoded data (only applicable if
+	// the encoding is `csv`).
+	CSVDelimiter string `json:"csv.delimiter" default:","`
+	// Whether to add a header line with the field names to CSV encoded data
+	// (only applicable if the encoding is `csv`).
+	CSVHeader bool `json:"csv.header"`
+	// Whether to prefix Avro encoded data with the Confluent wire format
+	// header, i.e. a magic byte and the schema ID (only applicable if the
+	// encoding is `avro`). The Avro schema is derived from the field specs.
+	AvroConfluent bool `json:"avro.confluent"`
+	// The schema ID written in the Confluent wire format header. If 0, the
+	// schema is registered in the schema registry and the assigned ID is used.
+	AvroSchemaID int `json:"avro.schemaID" validate:"gt=-1"`
+	// The CDC envelope the generated changes are wrapped in (none, debezium,
+	// maxwell, canal). The envelope is a JSON document stored as raw data in
+	// the payload after the change, it contains the data before and after the
+	// change matching the generated operation.
+	Envelope string `json:"envelope" default:"none" validate:"inclusion=none|debezium|maxwell|canal"`
+	// The target size of generated payloads in bytes (only applicable if the
+	// format type is `raw`, `structured` or `sample`). Accepts a fixed size
+	// (e.g. `4KB`), a uniform distribution (e.g. `uniform(1KB,64KB)`) or a
+	// log-normal distribution with a median and sigma (e.g.
+	// `lognormal(4KB,0.5)`). Payloads are padded with random letters in the
+	// field `filler`, payloads exceeding the target size are not truncated.
+	// The actual size is stored in the metadata field `generator.payloadSize`.
+	PayloadSize string `json:"payloadSize"`
+	// The codec used to compress raw payloads (none, gzip, snappy, zstd, lz4).
+	// Only applicable if the format type is `raw` or `file`, or if an
+	// envelope is configured. Snappy uses the block format, LZ4 the frame
+	// format.
+	Compression string `json:"compression" default:"none" validate:"inclusion=none|gzip|snappy|zstd|lz4"`
+	// Whether to store the compression codec in the metadata field
+	// `generator.compression`.
+	CompressionMetadata bool `json:"compression.metadata"`
 }
 
 func (c Config) Validate(context.Context) error {
@@ -91,6 +428,15 @@ func (c Config) Validate(context.Context) error {
 		errs = append(errs, errors.New(`"rate" should be greater or equal to 0`))
 	}
 
+	// Validate rate profile.
+	if c.RateProfile != "" && c.RateProfile != internal.RateProfileNone {
+		errs = append(errs, c.validateRateProfile()...)
+	}
+
+	if c.RateJitter < 0 || c.RateJitter > 1 {
+		errs = append(errs, errors.New(`"rate.jitter" should be between 0 and 1`))
+	}
+
 	// Validate burst.
 	if c.Burst.SleepTime < 0 {
 		errs = append(errs, errors.New(`"burst.sleepTime" should be greater or equal to 0`))
@@ -99,6 +445,66 @@ func (c Config) Validate(context.Context) error {
 		errs = append(errs, errors.New(`"burst.generateTime" should be greater than 0`))
 	}
 
+	// Validate faults.
+	if c.Faults.Probability < 0 || c.Faults.Probability > 1 {
+		errs = append(errs, errors.New(`"faults.probability" should be between 0 and 1`))
+	}
+	for _, at := range c.Faults.At {
+		if at < 0 {
+			errs = append(errs, errors.New(`"faults.at" should only contain durations greater or equal to 0`))
+			break
+		}
+	}
+
+	// Validate duplicates.
+	if c.Duplicates.Probability < 0 || c.Duplicates.Probability > 1 {
+		errs = append(errs, errors.New(`"duplicates.probability" should be between 0 and 1`))
+	}
+	if c.Duplicates.Delay < 0 {
+		errs = append(errs, errors.New(`"duplicates.delay" should be greater or equal to 0`))
+	}
+	if c.Duplicates.ReplayMaxSize != "" {
+		if _, err := internal.ParseByteSize(c.Duplicates.ReplayMaxSize); err != nil {
+			errs = append(errs, fmt.Errorf(`invalid "duplicates.replayMaxSize": %w`, err))
+		}
+	}
+
+	// Validate clock.
+	if c.Clock.Start != "" {
+		if _, err := time.Parse(time.RFC3339, c.Clock.Start); err != nil {
+			errs = append(errs, fmt.Errorf(`invalid "clock.start": %w`, err))
+		}
+		if c.Clock.Speed <= 0 {
+			errs = append(errs, errors.New(`"clock.speed" should be greater than 0`))
+		}
+		if c.Clock.Step < 0 {
+			errs = append(errs, errors.New(`"clock.step" should be greater or equal to 0`))
+		}
+	}
+
+	// Validate schedule.
+	if c.MaxDuration < 0 {
+		errs = append(errs, errors.New(`"maxDuration" should be greater or equal to 0`))
+	}
+	if _, err := c.GetSchedule(); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Validate trace.
+	if c.Trace.Path != "" {
+		if c.Rate > 0 || c.ReadTime > 0 || c.Burst.SleepTime > 0 || (c.RateProfile != "" && c.RateProfile != internal.RateProfileNone) {
+			errs = append(errs, errors.New(`"trace.path" cannot be combined with "rate", "readTime" or "burst"`))
+		}
+		if c.Trace.Speed <= 0 {
+			errs = append(errs, errors.New(`"trace.speed" should be greater than 0`))
+		}
+	}
+
+	// Validate phases.
+	if len(c.Phases) > 0 {
+		errs = append(errs, c.validatePhases()...)
+	}
+
 	// Validate collections.
 	collections := c.GetCollectionConfigs()
 	if len(collections) == 0 {
@@ -119,6 +525,212 @@ func (c Config) Validate(context.Context) error {
 	return errors.Join(errs...)
 }
 
+func (c Config) validateRateProfile() []error {
+	var errs []error
+	if c.RatePeriod <= 0 {
+		errs = append(errs, errors.New(`"rate.period" should be greater than 0 when using a rate profile`))
+	}
+	if c.RateProfile == internal.RateProfileStep {
+		if len(c.RateSteps) == 0 {
+			errs = append(errs, errors.New(`"rate.steps" needs to be set when using the step rate profile`))
+		}
+		for _, r := range c.RateSteps {
+			if r <= 0 {
+				errs = append(errs, errors.New(`"rate.steps" should only contain rates greater than 0`))
+				break
+			}
+		}
+		return errs
+	}
+	if c.Rate <= 0 {
+		errs = append(errs, errors.New(`"rate" should be greater than 0 when using a rate profile`))
+	}
+	if c.RateMin < 0 || c.RateMin > c.Rate {
+		errs = append(errs, errors.New(`"rate.min" should be between 0 and "rate"`))
+	}
+	return errs
+}
+
+func (c Config) validatePhases() []error {
+	var errs []error
+	if c.Trace.Path != "" || (c.RateProfile != "" && c.RateProfile != internal.RateProfileNone) {
+		errs = append(errs, errors.New(`"phases" cannot be combined with "trace.path" or "rate.profile"`))
+	}
+	collections := c.GetCollectionConfigs()
+	for name, p := range c.Phases {
+		if p.Duration < 0 {
+			errs = append(errs, fmt.Errorf(`phase %q: "duration" should be greater or equal to 0`, name))
+		}
+		if p.Rate != nil && *p.Rate < 0 {
+			errs = append(errs, fmt.Errorf(`phase %q: "rate" should be greater or equal to 0`, name))
+		}
+		if p.BurstSleepTime != nil && *p.BurstSleepTime < 0 {
+			errs = append(errs, fmt.Errorf(`phase %q: "burst.sleepTime" should be greater or equal to 0`, name))
+		}
+		if p.BurstGenerateTime != nil && *p.BurstGenerateTime <= 0 {
+			errs = append(errs, fmt.Errorf(`phase %q: "burst.generateTime" should be greater than 0`, name))
+		}
+		if _, err := (CollectionConfig{Operations: p.Operations}).parseOperations(); err != nil {
+			errs = append(errs, fmt.Errorf("phase %q: %w", name, err))
+		}
+		for _, collection := range p.Collections {
+			if _, ok := collections[collection]; !ok {
+				errs = append(errs, fmt.Errorf("phase %q: unknown collection %q", name, collection))
+			}
+		}
+	}
+	return errs
+}
+
+// GetPhases returns the phases in the order they are executed.
+func (c Config) GetPhases() []PhaseConfig {
+	names := make([]string, 0, len(c.Phases))
+	for name := range c.Phases {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		n, errA := strconv.Atoi(a)
+		m, errB := strconv.Atoi(b)
+		switch {
+		case errA == nil && errB == nil:
+			return cmp.Compare(n, m)
+		case errA == nil:
+			return -1 // numeric names come first
+		case errB == nil:
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+
+	phases := make([]PhaseConfig, len(names))
+	for i, name := range names {
+		phases[i] = c.Phases[name]
+	}
+	return phases
+}
+
+// RateLimit returns the rate limit in the phase, falling back to the rate
+// limit of the config if the phase doesn't override it.
+func (p PhaseConfig) RateLimit(c Config) rate.Limit {
+	if p.Rate == nil {
+		return c.RateLimit()
+	}
+	return rate.Limit(*p.Rate)
+}
+
+// GetBurst returns the burst configuration in the phase, falling back to the
+// burst configuration of the config for values the phase doesn't override.
+func (p PhaseConfig) GetBurst(c Config) BurstConfig {
+	burst := c.Burst
+	if p.BurstSleepTime != nil {
+		burst.SleepTime = *p.BurstSleepTime
+	}
+	if p.BurstGenerateTime != nil {
+		burst.GenerateTime = *p.BurstGenerateTime
+	}
+	return burst
+}
+
+// SdkOperations returns the operations in the phase, or nil if the phase
+// doesn't override them.
+func (p PhaseConfig) SdkOperations() []opencdc.Operation {
+	if len(p.Operations) == 0 {
+		return nil
+	}
+	// We can safely ignore the error here, it has been validated.
+	op, _ := (CollectionConfig{Operations: p.Operations}).parseOperations()
+	return op
+}
+
+// GetRateProfile returns the rate profile.
+func (c Config) GetRateProfile() internal.RateProfile {
+	return internal.RateProfile{
+		Type:   c.RateProfile,
+		Min:    c.RateMin,
+		Max:    c.Rate,
+		Period: c.RatePeriod,
+		Steps:  c.RateSteps,
+	}
+}
+
+// GetSchedule returns the schedule defined by `startAt`, `stopAt` and
+// `windows`.
+func (c Config) GetSchedule() (internal.Schedule, error) {
+	var schedule internal.Schedule
+	var errs []error
+	if c.StartAt != "" {
+		t, err := time.Parse(time.RFC3339, c.StartAt)
+		if err != nil {
+			errs = append(errs, fmt.Errorf(`invalid "startAt": %w`, err))
+		}
+		schedule.StartAt = t
+	}
+	if c.StopAt != "" {
+		t, err := time.Parse(time.RFC3339, c.StopAt)
+		if err != nil {
+			errs = append(errs, fmt.Errorf(`invalid "stopAt": %w`, err))
+		}
+		schedule.StopAt = t
+	}
+	for _, raw := range c.Windows {
+		w, err := internal.ParseWindow(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf(`invalid "windows": %w`, err))
+			continue
+		}
+		schedule.Windows = append(schedule.Windows, w)
+	}
+	return schedule, errors.Join(errs...)
+}
+
+// GetClock returns the simulated clock, or nil if the wall clock is used.
+func (c Config) GetClock() *internal.SimulatedClock {
+	if c.Clock.Start == "" {
+		return nil
+	}
+	// We can safely ignore the error here, it has been validated.
+	start, _ := time.Parse(time.RFC3339, c.Clock.Start)
+	return internal.NewSimulatedClock(start, c.Clock.Speed, c.Clock.Step)
+}
+
+// GetRand returns the source of random values, seeded with the configured
+// seed or randomly if it's not set.
+func (c Config) GetRand() *rand.Rand {
+	seed := c.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// GetFaultInjector returns the fault injector for reading records.
+func (c Config) GetFaultInjector() *internal.FaultInjector {
+	at := slices.Clone(c.Faults.At)
+	slices.Sort(at)
+	return &internal.FaultInjector{
+		AfterRecords: c.Faults.AfterRecords,
+		Probability:  c.Faults.Probability,
+		At:           at,
+		Kind:         c.Faults.Kind,
+	}
+}
+
+// GetReplayMaxSize returns the maximum total size of the records stored for
+// replaying, 0 if the size is not limited.
+func (c Config) GetReplayMaxSize() int {
+	// We can safely ignore the error here, it has been validated.
+	size, _ := internal.ParseByteSize(c.Duplicates.ReplayMaxSize)
+	return size
+}
+
+// GetArrivalModel returns the model of the time between generated records.
+func (c Config) GetArrivalModel() internal.ArrivalModel {
+	return internal.ArrivalModel{
+		Type:   c.RateArrival,
+		Jitter: c.RateJitter,
+	}
+}
+
 func (c Config) RateLimit() rate.Limit {
 	if c.Rate == 0 && c.ReadTime > 0 {
 		// Convert read time to rate limit.
@@ -149,10 +761,135 @@ func (c CollectionConfig) Validate() error {
 	if err != nil {
 		errs = append(errs, fmt.Errorf("failed validating format: %w", err))
 	}
+	err = c.validateEvolution()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed validating evolution: %w", err))
+	}
+	err = c.validateMalformed()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed validating malformed: %w", err))
+	}
+	err = c.validateEventTime()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed validating event time: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c CollectionConfig) validateEvolution() error {
+	if len(c.Evolution.Steps) == 0 {
+		return nil
+	}
+	switch c.Format.Type {
+	case FormatTypeRaw, FormatTypeStructured, FormatTypeSample:
+	default:
+		return fmt.Errorf("schema evolution is not supported for format type %q", c.Format.Type)
+	}
+	if c.Evolution.Interval < 0 {
+		return errors.New(`"evolution.interval" should be greater or equal to 0`)
+	}
+	if c.Evolution.Records == 0 && c.Evolution.Interval == 0 {
+		return errors.New(`"evolution.records" or "evolution.interval" needs to be set`)
+	}
+
+	evolution, err := c.SchemaEvolution()
+	if err != nil {
+		return err
+	}
+	if c.Format.Type == FormatTypeSample {
+		return nil // fields are only known once the samples are read
+	}
+	fields, err := internal.ParseFields(c.Format.Options)
+	if err != nil {
+		return nil // reported when validating the format
+	}
+	if c.Format.Encoding == internal.EncodingXML || c.Format.Encoding == internal.EncodingAvro {
+		var names []string
+		for _, step := range evolution.Steps {
+			switch step.Op {
+			case internal.EvolutionAdd:
+				names = append(names, step.Field)
+			case internal.EvolutionRename:
+				names = append(names, step.Arg)
+			}
+		}
+		if err := validateFieldNames(c.Format.Encoding, names); err != nil {
+			return err
+		}
+	}
+	return evolution.Validate(fields)
+}
+
+func (c CollectionConfig) validateMalformed() error {
+	var errs []error
+	if c.Malformed.Probability < 0 || c.Malformed.Probability > 1 {
+		errs = append(errs, errors.New(`"malformed.probability" should be between 0 and 1`))
+	}
+	for _, kind := range c.Malformed.Kinds {
+		if !slices.Contains(internal.MalformedKinds, kind) {
+			errs = append(errs, fmt.Errorf("unknown malformation %q", kind))
+		}
+	}
+	if c.Malformed.OversizedSize != "" {
+		if _, err := internal.ParseByteSize(c.Malformed.OversizedSize); err != nil {
+			errs = append(errs, fmt.Errorf(`invalid "malformed.oversizedSize": %w`, err))
+		}
+	}
+	return errors.Join(errs...)
+}
 
+// GetMalformed returns how payloads in the collection are malformed.
+func (c CollectionConfig) GetMalformed() internal.Malformed {
+	// We can safely ignore the error here, it has been validated.
+	size, _ := internal.ParseByteSize(c.Malformed.OversizedSize)
+	return internal.Malformed{
+		Probability:   c.Malformed.Probability,
+		Kinds:         c.Malformed.Kinds,
+		OversizedSize: size,
+	}
+}
+
+func (c CollectionConfig) validateEventTime() error {
+	var errs []error
+	if c.EventTime.MaxOutOfOrderness < 0 {
+		errs = append(errs, errors.New(`"eventTime.maxOutOfOrderness" should be greater or equal to 0`))
+	}
+	if c.EventTime.LateRatio < 0 || c.EventTime.LateRatio > 1 {
+		errs = append(errs, errors.New(`"eventTime.lateRatio" should be between 0 and 1`))
+	}
+	if _, err := internal.ParseLateness(c.EventTime.Lateness); err != nil {
+		errs = append(errs, fmt.Errorf(`invalid "eventTime.lateness": %w`, err))
+	}
 	return errors.Join(errs...)
 }
 
+// GetEventTime returns the event time model of the collection.
+func (c CollectionConfig) GetEventTime() *internal.EventTime {
+	// We can safely ignore the error here, it has been validated.
+	lateness, _ := internal.ParseLateness(c.EventTime.Lateness)
+	return &internal.EventTime{
+		Model:             c.EventTime.Model,
+		MaxOutOfOrderness: c.EventTime.MaxOutOfOrderness,
+		LateRatio:         c.EventTime.LateRatio,
+		Lateness:          lateness,
+		Skew:              c.EventTime.Skew,
+	}
+}
+
+// SchemaEvolution returns the schema evolution of the collection.
+func (c CollectionConfig) SchemaEvolution() (internal.Evolution, error) {
+	steps, err := internal.ParseEvolutionSteps(c.Evolution.Steps)
+	if err != nil {
+		return internal.Evolution{}, err
+	}
+	return internal.Evolution{
+		Records:  c.Evolution.Records,
+		Interval: c.Evolution.Interval,
+		Steps:    steps,
+	}, nil
+}
+
 func (c CollectionConfig) SdkOperations() []opencdc.Operation {
 	// We can safely ignore the error here, it has been validated.
 	op, _ := c.parseOperations()
@@ -174,7 +911,7 @@ func (c CollectionConfig) parseOperations() ([]opencdc.Operation, error) {
 
 func (c FormatConfig) Validate() error {
 	switch c.Type {
-	case FormatTypeFile:
+	case FormatTypeFile, FormatTypeAvro, FormatTypeSQL, FormatTypeProtobuf, FormatTypeSample:
 		if c.FileOptionsPath == "" {
 			return errors.New("file path not specified")
 		}
@@ -186,9 +923,87 @@ func (c FormatConfig) Validate() error {
 	default:
 		return fmt.Errorf("unknown format type %q", c.Type)
 	}
+	return errors.Join(c.validateEncoding(), c.validatePayloadSize(), c.validateCompression())
+}
+
+func (c FormatConfig) validatePayloadSize() error {
+	if c.PayloadSize == "" {
+		return nil
+	}
+	switch c.Type {
+	case FormatTypeRaw, FormatTypeStructured, FormatTypeSample:
+	default:
+		return fmt.Errorf(`"payloadSize" is not supported for format type %q`, c.Type)
+	}
+	if _, ok := c.Options[internal.FillerField]; ok {
+		return fmt.Errorf(`field %q is reserved for padding payloads when "payloadSize" is set`, internal.FillerField)
+	}
+	if _, err := internal.ParsePayloadSize(c.PayloadSize); err != nil {
+		return fmt.Errorf(`invalid "payloadSize": %w`, err)
+	}
+	return nil
+}
+
+func (c FormatConfig) validateCompression() error {
+	if c.Compression == "" || c.Compression == internal.CompressionNone {
+		return nil
+	}
+	if c.Type != FormatTypeRaw && c.Type != FormatTypeFile && (c.Envelope == "" || c.Envelope == internal.EnvelopeNone) {
+		return fmt.Errorf(`"compression" is not supported for format type %q without an envelope`, c.Type)
+	}
 	return nil
 }
 
+// TargetPayloadSize returns the target size of generated payloads.
+func (c FormatConfig) TargetPayloadSize() internal.PayloadSize {
+	// We can safely ignore the error here, it has been validated.
+	size, _ := internal.ParsePayloadSize(c.PayloadSize)
+	return size
+}
+
+func (c FormatConfig) validateEncoding() error {
+	if c.Encoding != "" && c.Encoding != internal.EncodingJSON && c.Type != FormatTypeRaw {
+		return fmt.Errorf("encoding %q is only supported for format type %q", c.Encoding, FormatTypeRaw)
+	}
+	if c.Encoding == internal.EncodingCSV && c.CSVDelimiter != "" && utf8.RuneCountInString(c.CSVDelimiter) != 1 {
+		return fmt.Errorf(`"csv.delimiter" should be a single character, got %q`, c.CSVDelimiter)
+	}
+	if c.AvroSchemaID > math.MaxUint32 {
+		return fmt.Errorf(`"avro.schemaID" should be at most %d`, uint32(math.MaxUint32))
+	}
+	return validateFieldNames(c.Encoding, slices.Sorted(maps.Keys(c.Options)))
+}
+
+// validateFieldNames returns an error for each field name that can't be used
+// with the encoding, i.e. as an XML element name or an Avro field name.
+func validateFieldNames(encoding string, names []string) error {
+	var errs []error
+	for _, name := range names {
+		switch {
+		case encoding == internal.EncodingXML && !internal.IsXMLName(name):
+			errs = append(errs, fmt.Errorf("field %q is not a valid XML element name", name))
+		case encoding == internal.EncodingAvro && !internal.IsAvroName(name):
+			errs = append(errs, fmt.Errorf("field %q is not a valid Avro field name", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RawEncoding returns the encoding of raw data.
+func (c FormatConfig) RawEncoding() internal.RawEncoding {
+	delimiter, _ := utf8.DecodeRuneInString(c.CSVDelimiter)
+	if delimiter == utf8.RuneError {
+		delimiter = 0 // use the default delimiter
+	}
+	return internal.RawEncoding{
+		Type:          c.Encoding,
+		CSVDelimiter:  delimiter,
+		CSVHeader:     c.CSVHeader,
+		AvroConfluent: c.AvroConfluent,
+		AvroSchemaID:  c.AvroSchemaID,
+	}
+}
+
 func (c FormatConfig) validateFields(fields map[string]string) error {
 	var errs []error
 	for f, t := range fields {
@@ -200,14 +1015,20 @@ func (c FormatConfig) validateFields(fields map[string]string) error {
 		}
 		if !c.knownType(t) {
 			errs = append(errs, fmt.Errorf("unknown data type in %q", f))
+			continue
+		}
+		if _, err := internal.ParseField(f, t); err != nil {
+			errs = append(errs, fmt.Errorf("invalid field spec in %q: %w", f, err))
 		}
 	}
 	return errors.Join(errs...)
 }
 
-func (c FormatConfig) knownType(typeString string) bool {
+func (c FormatConfig) knownType(spec string) bool {
+	// the type can be followed by parameters, e.g. int(min=1)
+	typeString, _, _ := strings.Cut(spec, "(")
 	for _, t := range internal.KnownTypes {
-		if strings.ToLower(typeString) == t {
+		if strings.ToLower(strings.TrimSpace(typeString)) == t {
 			return true
 		}
 	}
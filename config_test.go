@@ -17,6 +17,7 @@ package generator
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/matryer/is"
 )
@@ -83,6 +84,198 @@ func TestConfig_Validate(t *testing.T) {
 			},
 		},
 		wantErr: `failed validating default collection: failed validating format: failed parsing fields: unknown data type in "abc"`,
+	}, {
+		name: "evolution",
+		have: Config{
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{
+					Type:    "structured",
+					Options: map[string]string{"id": "int"},
+				},
+				Evolution: EvolutionConfig{
+					Records: 10,
+					Steps:   []string{"add:name:string", "widen:id:float", "rename:name:fullName", "drop:id"},
+				},
+			},
+		},
+	}, {
+		name: "evolution, missing field",
+		have: Config{
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{
+					Type:    "structured",
+					Options: map[string]string{"id": "int"},
+				},
+				Evolution: EvolutionConfig{
+					Records: 10,
+					Steps:   []string{"drop:id", "rename:id:key"},
+				},
+			},
+		},
+		wantErr: `failed validating default collection: failed validating evolution: schema evolution step "rename:id:key": field "id" does not exist`,
+	}, {
+		name: "evolution, no trigger",
+		have: Config{
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{
+					Type:    "structured",
+					Options: map[string]string{"id": "int"},
+				},
+				Evolution: EvolutionConfig{
+					Steps: []string{"drop:id"},
+				},
+			},
+		},
+		wantErr: `failed validating default collection: failed validating evolution: "evolution.records" or "evolution.interval" needs to be set`,
+	}, {
+		name: "evolution, unsupported format",
+		have: Config{
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{
+					Type:            "file",
+					FileOptionsPath: "/path/to/file.txt",
+				},
+				Evolution: EvolutionConfig{
+					Records: 10,
+					Steps:   []string{"drop:id"},
+				},
+			},
+		},
+		wantErr: `failed validating default collection: failed validating evolution: schema evolution is not supported for format type "file"`,
+	}, {
+		name: "payload size, invalid distribution",
+		have: Config{
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{
+					Type:        "structured",
+					Options:     map[string]string{"id": "int"},
+					PayloadSize: "normal(1KB,2KB)",
+				},
+			},
+		},
+		wantErr: `failed validating default collection: failed validating format: invalid "payloadSize": unknown size distribution "normal"`,
+	}, {
+		name: "rate profile",
+		have: Config{
+			Rate:        100,
+			RateProfile: "sine",
+			RateMin:     10,
+			RatePeriod:  time.Minute,
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{Type: "raw", Options: map[string]string{"id": "int"}},
+			},
+		},
+	}, {
+		name: "rate profile, missing rate",
+		have: Config{
+			RateProfile: "ramp",
+			RatePeriod:  time.Minute,
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{Type: "raw", Options: map[string]string{"id": "int"}},
+			},
+		},
+		wantErr: `"rate" should be greater than 0 when using a rate profile`,
+	}, {
+		name: "invalid window",
+		have: Config{
+			Windows: []string{"09:00-25:00"},
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{Type: "raw", Options: map[string]string{"id": "int"}},
+			},
+		},
+		wantErr: `invalid "windows": invalid time of day "25:00", expected HH:MM`,
+	}, {
+		name: "phase with unknown collection",
+		have: Config{
+			Phases: map[string]PhaseConfig{
+				"1": {RecordCount: 10, Collections: []string{"users"}},
+			},
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{Type: "raw", Options: map[string]string{"id": "int"}},
+			},
+		},
+		wantErr: `phase "1": unknown collection "users"`,
+	}, {
+		name: "invalid lateness",
+		have: Config{
+			CollectionConfig: CollectionConfig{
+				Format:    FormatConfig{Type: "raw", Options: map[string]string{"id": "int"}},
+				EventTime: EventTimeConfig{Lateness: "uniform(5m,1m)"},
+			},
+		},
+		wantErr: `failed validating default collection: failed validating event time: invalid "eventTime.lateness": min lateness 5m0s is greater than max lateness 1m0s`,
+	}, {
+		name: "invalid xml field names",
+		have: Config{
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{
+					Type:     "raw",
+					Encoding: "xml",
+					Options: map[string]string{
+						"id":         "int",
+						"first name": "string",
+						"2nd":        "string",
+						"ns:tag":     "string",
+					},
+				},
+			},
+		},
+		wantErr: "failed validating default collection: failed validating format: " +
+			"field \"2nd\" is not a valid XML element name\n" +
+			"field \"first name\" is not a valid XML element name\n" +
+			"field \"ns:tag\" is not a valid XML element name",
+	}, {
+		name: "invalid xml field name in evolution",
+		have: Config{
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{
+					Type:     "raw",
+					Encoding: "xml",
+					Options:  map[string]string{"id": "int"},
+				},
+				Evolution: EvolutionConfig{
+					Records: 10,
+					Steps:   []string{"rename:id:user id"},
+				},
+			},
+		},
+		wantErr: `failed validating default collection: failed validating evolution: field "user id" is not a valid XML element name`,
+	}, {
+		name: "invalid avro field names",
+		have: Config{
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{
+					Type:     "raw",
+					Encoding: "avro",
+					Options: map[string]string{
+						"id":       "int",
+						"order-id": "int",
+						"1st":      "string",
+						"créé":     "time",
+					},
+				},
+			},
+		},
+		wantErr: "failed validating default collection: failed validating format: " +
+			"field \"1st\" is not a valid Avro field name\n" +
+			"field \"créé\" is not a valid Avro field name\n" +
+			"field \"order-id\" is not a valid Avro field name",
+	}, {
+		name: "invalid avro field name in evolution",
+		have: Config{
+			CollectionConfig: CollectionConfig{
+				Format: FormatConfig{
+					Type:     "raw",
+					Encoding: "avro",
+					Options:  map[string]string{"id": "int"},
+				},
+				Evolution: EvolutionConfig{
+					Records: 10,
+					Steps:   []string{"add:user.id:string"},
+				},
+			},
+		},
+		wantErr: `failed validating default collection: failed validating evolution: field "user.id" is not a valid Avro field name`,
 	}}
 
 	for _, tc := range testCases {
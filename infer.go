@@ -0,0 +1,88 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/conduitio/conduit-connector-generator/internal"
+)
+
+// InferCollectionConfig reads the sample records in the JSONL or CSV file at
+// the given path and returns a collection config generating structured records
+// that statistically resemble the samples. Field types, value ranges,
+// cardinalities, null ratios and enum candidates are inferred from the
+// samples.
+func InferCollectionConfig(path string) (CollectionConfig, error) {
+	fields, err := internal.InferFields(path)
+	if err != nil {
+		return CollectionConfig{}, fmt.Errorf("failed to infer fields: %w", err)
+	}
+
+	options := make(map[string]string, len(fields))
+	for _, f := range fields {
+		options[f.Name] = f.String()
+	}
+	return CollectionConfig{
+		Operations: []string{"create"},
+		Format: FormatConfig{
+			Type:    FormatTypeStructured,
+			Options: options,
+		},
+	}, nil
+}
+
+// Settings returns the connector settings describing the collection config.
+// The keys are prefixed with prefix, e.g. "collections.users.".
+func (c CollectionConfig) Settings(prefix string) map[string]string {
+	settings := map[string]string{
+		prefix + "operations":  strings.Join(c.Operations, ","),
+		prefix + "format.type": c.Format.Type,
+	}
+	for k, v := range c.Format.Options {
+		settings[prefix+"format.options."+k] = v
+	}
+	if c.Format.FileOptionsPath != "" {
+		settings[prefix+"format.options.path"] = c.Format.FileOptionsPath
+	}
+	return settings
+}
+
+// writeSettings writes the settings as a YAML map to the file at the given
+// path, so they can be copied into a pipeline configuration file.
+func writeSettings(path string, settings map[string]string) error {
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteString(": ")
+		sb.WriteString(strconv.Quote(settings[k]))
+		sb.WriteString("\n")
+	}
+	//nolint:gosec // the file contains no secrets, it's meant to be shared
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
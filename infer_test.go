@@ -0,0 +1,93 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+)
+
+func TestInferCollectionConfig(t *testing.T) {
+	want := CollectionConfig{
+		Operations: []string{"create"},
+		Format: FormatConfig{
+			Type: FormatTypeStructured,
+			Options: map[string]string{
+				"id":        "int(min=1,max=6)",
+				"status":    "string(values=new|paid|shipped)",
+				"amount":    "float(min=5,max=99.99)",
+				"customer":  "string(values=alice|bob|carol)",
+				"paid":      "bool",
+				"note":      "string(null=0.667)",
+				"createdAt": "time",
+			},
+		},
+	}
+
+	for _, path := range []string{"./testdata/orders.jsonl", "./testdata/orders.csv"} {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			is := is.New(t)
+			got, err := InferCollectionConfig(path)
+			is.NoErr(err)
+			is.Equal(got, want)
+			is.NoErr(got.Validate())
+		})
+	}
+}
+
+func TestSource_Read_Sample(t *testing.T) {
+	is := is.New(t)
+	output := filepath.Join(t.TempDir(), "settings.yaml")
+	underTest := openTestSource(
+		t,
+		map[string]string{
+			"collections.orders.format.type":           "sample",
+			"collections.orders.format.options.path":   "./testdata/orders.jsonl",
+			"collections.orders.format.options.output": output,
+			"collections.orders.operations":            "snapshot",
+		},
+	)
+
+	for range 20 {
+		rec, err := underTest.Read(context.Background())
+		is.NoErr(err)
+		is.Equal(rec.Operation, opencdc.OperationSnapshot)
+
+		v, ok := rec.Payload.After.(opencdc.StructuredData)
+		is.True(ok)
+		is.Equal(len(v), 7)
+		id := v["id"].(int)
+		is.True(id >= 1 && id <= 6)
+		is.True(v["status"] == "new" || v["status"] == "paid" || v["status"] == "shipped")
+	}
+
+	got, err := os.ReadFile(output)
+	is.NoErr(err)
+	is.Equal(string(got), `collections.orders.format.options.amount: "float(min=5,max=99.99)"
+collections.orders.format.options.createdAt: "time"
+collections.orders.format.options.customer: "string(values=alice|bob|carol)"
+collections.orders.format.options.id: "int(min=1,max=6)"
+collections.orders.format.options.note: "string(null=0.667)"
+collections.orders.format.options.paid: "bool"
+collections.orders.format.options.status: "string(values=new|paid|shipped)"
+collections.orders.format.type: "structured"
+collections.orders.operations: "snapshot"
+`)
+}
@@ -0,0 +1,181 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+// Kinds of acknowledgment violations.
+const (
+	AckUnknown    = "unknown"
+	AckDuplicate  = "duplicate"
+	AckOutOfOrder = "out of order"
+)
+
+// AckTracker tracks emitted positions and verifies that every position is
+// acknowledged once and in the order it was emitted. It measures the latency
+// between emitting and acknowledging positions. It is safe for concurrent use.
+type AckTracker struct {
+	mu    sync.Mutex
+	clock Clock
+	// pending contains the emissions of positions that were not acknowledged
+	// yet. A position emitted multiple times has multiple emissions.
+	pending map[string][]emission
+	// emissions is the number of emitted positions, lastAcked the emission
+	// number of the last acknowledged position.
+	emissions int64
+	lastAcked int64
+	// firstSeq and lastSeq are the lowest and highest sequence numbers of
+	// emitted positions.
+	firstSeq, lastSeq int64
+
+	stats AckStats
+	// latency contains the latencies since the last call to
+	// IntervalLatency, totalLatency all latencies.
+	latency, totalLatency *LatencyHistogram
+}
+
+type emission struct {
+	// n is the number of the emission, emissions are numbered in the order
+	// positions are emitted.
+	n  int64
+	at time.Time
+}
+
+// AckStats contains the number of acknowledged positions and violations.
+type AckStats struct {
+	Emitted    int64
+	Acked      int64
+	Unknown    int64
+	Duplicate  int64
+	OutOfOrder int64
+	// Missing is the number of emitted positions that were not
+	// acknowledged.
+	Missing int64
+}
+
+// NewAckTracker creates a tracker of acknowledgments, which measures
+// latencies with the clock.
+func NewAckTracker(clock Clock) *AckTracker {
+	return &AckTracker{
+		clock:        clock,
+		pending:      make(map[string][]emission),
+		firstSeq:     -1,
+		latency:      NewLatencyHistogram(),
+		totalLatency: NewLatencyHistogram(),
+	}
+}
+
+// Emitted records that the position with the given sequence number was
+// emitted.
+func (t *AckTracker) Emitted(pos opencdc.Position, seq int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.emissions++
+	t.pending[string(pos)] = append(t.pending[string(pos)], emission{n: t.emissions, at: t.clock.Now()})
+	if t.firstSeq < 0 || seq < t.firstSeq {
+		t.firstSeq = seq
+	}
+	t.lastSeq = max(t.lastSeq, seq)
+	t.stats.Emitted++
+}
+
+// Acked records the acknowledgment of the position and returns the kind of
+// violation, or an empty string if the acknowledgment is valid.
+func (t *AckTracker) Acked(pos opencdc.Position) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	emissions, ok := t.pending[string(pos)]
+	if !ok {
+		// positions acknowledged before are not tracked anymore, they are
+		// identified by their sequence number
+		p, err := ParsePosition(pos)
+		if err == nil && t.firstSeq >= 0 && p.Seq >= t.firstSeq && p.Seq <= t.lastSeq {
+			t.stats.Duplicate++
+			return AckDuplicate
+		}
+		t.stats.Unknown++
+		return AckUnknown
+	}
+
+	t.stats.Acked++
+	e := emissions[0]
+	if len(emissions) == 1 {
+		delete(t.pending, string(pos))
+	} else {
+		t.pending[string(pos)] = emissions[1:]
+	}
+	latency := t.clock.Now().Sub(e.at)
+	t.latency.Record(latency)
+	t.totalLatency.Record(latency)
+
+	if e.n < t.lastAcked {
+		t.stats.OutOfOrder++
+		return AckOutOfOrder
+	}
+	t.lastAcked = e.n
+	return ""
+}
+
+// Stats returns the number of acknowledged positions and violations so far.
+func (t *AckTracker) Stats() AckStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats
+	for _, emissions := range t.pending {
+		stats.Missing += int64(len(emissions))
+	}
+	return stats
+}
+
+// IntervalLatency returns the percentiles of the latencies between emitting
+// and acknowledging positions since the last call.
+func (t *AckTracker) IntervalLatency() LatencyStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.latency.Stats()
+	t.latency.Reset()
+	return stats
+}
+
+// TotalLatency returns the percentiles of all latencies between emitting and
+// acknowledging positions.
+func (t *AckTracker) TotalLatency() LatencyStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.totalLatency.Stats()
+}
+
+// Violations returns the total number of violations, including missing
+// acknowledgments.
+func (s AckStats) Violations() int64 {
+	return s.Unknown + s.Duplicate + s.OutOfOrder + s.Missing
+}
+
+func (s AckStats) String() string {
+	return fmt.Sprintf(
+		"emitted: %d, acked: %d, unknown: %d, duplicate: %d, out of order: %d, missing: %d",
+		s.Emitted, s.Acked, s.Unknown, s.Duplicate, s.OutOfOrder, s.Missing,
+	)
+}
@@ -0,0 +1,66 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Arrival models.
+const (
+	ArrivalConstant = "constant"
+	ArrivalPoisson  = "poisson"
+	ArrivalUniform  = "uniform"
+)
+
+// ArrivalModel describes the distribution of the time between generated
+// records.
+type ArrivalModel struct {
+	Type string
+	// Jitter is the maximum deviation of the time between records from its
+	// mean in the uniform model, as a fraction of the mean.
+	Jitter float64
+}
+
+// Constant returns true if records are evenly spaced.
+func (m ArrivalModel) Constant() bool {
+	return m.Type == "" || m.Type == ArrivalConstant
+}
+
+// Gap returns the time until the next record, so that records are generated
+// with the given mean rate in records per second.
+//   - constant: the gap is always 1/rate.
+//   - poisson: the gap is exponentially distributed, i.e. records arrive as a
+//     Poisson process.
+//   - uniform: the gap is uniformly distributed around 1/rate, deviating by
+//     at most Jitter times the mean.
+//
+// Random gaps are drawn from rnd.
+func (m ArrivalModel) Gap(rnd *rand.Rand, rate float64) time.Duration {
+	if rate <= 0 || math.IsInf(rate, 1) {
+		return 0
+	}
+	mean := float64(time.Second) / rate
+	switch m.Type {
+	case ArrivalPoisson:
+		return time.Duration(rnd.ExpFloat64() * mean)
+	case ArrivalUniform:
+		return time.Duration(mean * (1 + m.Jitter*(2*rnd.Float64()-1)))
+	default:
+		return time.Duration(mean)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-sdk/schema"
+	"github.com/hamba/avro/v2"
+)
+
+// avroMaxDepth limits how deep nested values are generated, so that recursive
+// schemas (e.g. linked lists) terminate.
+const avroMaxDepth = 5
+
+// NewAvroRecordGenerator creates a RecordGenerator that generates records with
+// structured data matching the Avro schema in the file at the given path. The
+// top level schema needs to be a record. The schema is registered with the
+// schema service under the given subject and attached to every generated
+// record, so the schema doesn't need to be extracted from the payload. Random
+// values are drawn from rnd.
+func NewAvroRecordGenerator(
+	ctx context.Context,
+	rnd *rand.Rand,
+	collection string,
+	operations []opencdc.Operation,
+	path string,
+	subject string,
+) (RecordGenerator, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	avroSchema, err := avro.ParseBytes(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse avro schema: %w", err)
+	}
+	if avroSchema.Type() != avro.Record {
+		return nil, fmt.Errorf("expected avro schema of type record, got %q", avroSchema.Type())
+	}
+
+	sch, err := schema.Create(ctx, schema.TypeAvro, subject, bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register avro schema: %w", err)
+	}
+
+	return &baseRecordGenerator{
+		rnd:           rnd,
+		collection:    collection,
+		operations:    operations,
+		payloadSchema: &sch,
+		generateData: func(now time.Time) opencdc.Data {
+			return opencdc.StructuredData(randomAvroValue(rnd, avroSchema, 0, now).(map[string]any))
+		},
+	}, nil
+}
+
+// randomAvroValue generates a random value for the given Avro schema. The
+// returned values are in the shape expected by hamba/avro when marshaling.
+// Timestamps and dates are derived from now.
+func randomAvroValue(rnd *rand.Rand, s avro.Schema, depth int, now time.Time) any {
+	switch s := s.(type) {
+	case *avro.RefSchema:
+		return randomAvroValue(rnd, s.Schema(), depth, now)
+	case *avro.NullSchema:
+		return nil
+	case *avro.PrimitiveSchema:
+		return randomAvroPrimitive(rnd, s, now)
+	case *avro.RecordSchema:
+		data := make(map[string]any, len(s.Fields()))
+		for _, f := range s.Fields() {
+			data[f.Name()] = randomAvroValue(rnd, f.Type(), depth+1, now)
+		}
+		return data
+	case *avro.EnumSchema:
+		return s.Symbols()[rnd.Intn(len(s.Symbols()))]
+	case *avro.FixedSchema:
+		if l := s.Logical(); l != nil && l.Type() == avro.Decimal {
+			return randomAvroDecimal(rnd, l.(*avro.DecimalLogicalSchema))
+		}
+		// hamba/avro expects fixed values as byte arrays of the exact size
+		arr := reflect.New(reflect.ArrayOf(s.Size(), reflect.TypeOf(byte(0)))).Elem()
+		reflect.Copy(arr, reflect.ValueOf(randomBytes(rnd, s.Size())))
+		return arr.Interface()
+	case *avro.ArraySchema:
+		n := 0
+		if depth < avroMaxDepth {
+			n = rnd.Intn(4)
+		}
+		items := make([]any, n)
+		for i := range items {
+			items[i] = randomAvroValue(rnd, s.Items(), depth+1, now)
+		}
+		return items
+	case *avro.MapSchema:
+		n := 0
+		if depth < avroMaxDepth {
+			n = rnd.Intn(4)
+		}
+		values := make(map[string]any, n)
+		for range n {
+			values[randomWord(rnd)] = randomAvroValue(rnd, s.Values(), depth+1, now)
+		}
+		return values
+	case *avro.UnionSchema:
+		return randomAvroUnion(rnd, s, depth, now)
+	default:
+		panic(fmt.Errorf("unsupported avro schema type %q", s.Type()))
+	}
+}
+
+func randomAvroUnion(rnd *rand.Rand, s *avro.UnionSchema, depth int, now time.Time) any {
+	types := s.Types()
+	var branch avro.Schema
+	if nullIdx, _ := s.Indices(); s.Nullable() && depth >= avroMaxDepth {
+		// stop recursion by choosing the null branch
+		branch = types[nullIdx]
+	} else {
+		branch = types[rnd.Intn(len(types))]
+	}
+
+	if ref, ok := branch.(*avro.RefSchema); ok {
+		branch = ref.Schema()
+	}
+	val := randomAvroValue(rnd, branch, depth, now)
+
+	switch branch.Type() {
+	case avro.Null:
+		return nil
+	case avro.Record, avro.Enum, avro.Fixed:
+		// Named types can't be resolved from the Go type, so they need to be
+		// wrapped in a map keyed by the type name.
+		return map[string]any{branch.(avro.NamedSchema).FullName(): val}
+	case avro.Array, avro.Map:
+		return map[string]any{string(branch.Type()): val}
+	default:
+		return val
+	}
+}
+
+func randomAvroPrimitive(rnd *rand.Rand, s *avro.PrimitiveSchema, now time.Time) any {
+	if l := s.Logical(); l != nil {
+		switch l.Type() {
+		case avro.Date:
+			return now.UTC().Truncate(24 * time.Hour)
+		case avro.TimeMillis:
+			return time.Duration(rnd.Int63n(int64(24 * time.Hour))).Truncate(time.Millisecond)
+		case avro.TimeMicros:
+			return time.Duration(rnd.Int63n(int64(24 * time.Hour))).Truncate(time.Microsecond)
+		case avro.TimestampMillis, avro.LocalTimestampMillis:
+			return now.UTC().Truncate(time.Millisecond)
+		case avro.TimestampMicros, avro.LocalTimestampMicros:
+			return now.UTC().Truncate(time.Microsecond)
+		case avro.Decimal:
+			return randomAvroDecimal(rnd, l.(*avro.DecimalLogicalSchema))
+		case avro.UUID:
+			return randomUUID(rnd)
+		}
+	}
+
+	switch s.Type() {
+	case avro.Boolean:
+		return rnd.Int()%2 == 0
+	case avro.Int:
+		return int(rnd.Int31())
+	case avro.Long:
+		return rnd.Int63()
+	case avro.Float:
+		return rnd.Float32()
+	case avro.Double:
+		return rnd.Float64()
+	case avro.Bytes:
+		return []byte(randomWord(rnd))
+	case avro.String:
+		return randomWord(rnd)
+	default:
+		panic(fmt.Errorf("unsupported avro primitive type %q", s.Type()))
+	}
+}
+
+func randomAvroDecimal(rnd *rand.Rand, l *avro.DecimalLogicalSchema) *big.Rat {
+	// keep the unscaled value within the configured precision
+	digits := min(l.Precision(), 18)
+	unscaled := rnd.Int63n(int64(math.Pow10(digits)))
+	return new(big.Rat).SetFrac(
+		big.NewInt(unscaled),
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(l.Scale())), nil),
+	)
+}
+
+func randomUUID(rnd *rand.Rand) string {
+	b := randomBytes(rnd, 16)
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,139 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-sdk/schema"
+	"github.com/goccy/go-json"
+	"github.com/hamba/avro/v2"
+)
+
+// avroEncoder returns a function encoding data as Avro binary, using a schema
+// derived from the fields.
+func (e RawEncoding) avroEncoder(ctx context.Context, fields []Field) (func(opencdc.StructuredData) ([]byte, error), error) {
+	avroSchema, err := avroSchemaForFields(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var header []byte
+	if e.AvroConfluent {
+		id := e.AvroSchemaID
+		if id == 0 {
+			sch, err := schema.Create(ctx, schema.TypeAvro, e.AvroSubject, []byte(avroSchema.String()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to register avro schema: %w", err)
+			}
+			id = sch.ID
+		}
+		header = make([]byte, 5)
+		// header[0] is the magic byte 0
+		binary.BigEndian.PutUint32(header[1:], uint32(id)) //nolint:gosec // schema IDs are validated in the config
+	}
+
+	return func(data opencdc.StructuredData) ([]byte, error) {
+		values := make(map[string]any, len(fields))
+		for _, f := range fields {
+			values[f.Name] = avroFieldValue(data[f.Name])
+		}
+		bytes, err := avro.Marshal(avroSchema, values)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return bytes, nil
+		}
+		return append(append(make([]byte, 0, len(header)+len(bytes)), header...), bytes...), nil
+	}, nil
+}
+
+// avroSchemaForFields derives an Avro record schema from the fields. Fields
+// that can be null are unions of null and the field type.
+func avroSchemaForFields(fields []Field) (avro.Schema, error) {
+	type avroField struct {
+		Name string `json:"name"`
+		Type any    `json:"type"`
+	}
+	avroFields := make([]avroField, len(fields))
+	for i, f := range fields {
+		var typ any
+		switch f.Type {
+		case "int", "duration":
+			typ = "long"
+		case "float":
+			typ = "double"
+		case "bool":
+			typ = "boolean"
+		case "time":
+			typ = map[string]string{"type": "long", "logicalType": "timestamp-micros"}
+		default:
+			typ = "string"
+		}
+		if f.NullRatio > 0 {
+			typ = []any{"null", typ}
+		}
+		avroFields[i] = avroField{Name: f.Name, Type: typ}
+	}
+
+	bytes, err := json.Marshal(map[string]any{
+		"type":   "record",
+		"name":   "record",
+		"fields": avroFields,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't serialize avro schema: %w", err)
+	}
+	avroSchema, err := avro.ParseBytes(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create avro schema: %w", err)
+	}
+	return avroSchema, nil
+}
+
+// IsAvroName reports whether name can be used as an Avro name, which starts
+// with a letter or underscore followed by letters, digits or underscores.
+func IsAvroName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// avroFieldValue converts a generated value into the type expected by the
+// schema returned by avroSchemaForFields.
+func avroFieldValue(v any) any {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case time.Duration:
+		return int64(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import "time"
+
+// Clock provides the current time and timers. The source paces the generated
+// records with it, tests replace it to control the passing of time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time
+	// on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// WallClock is the Clock of the system.
+type WallClock struct{}
+
+func (WallClock) Now() time.Time {
+	return time.Now()
+}
+
+func (WallClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// SimulatedClock is a clock starting at an arbitrary time, which runs faster
+// or slower than the wall clock or advances with every call to Now.
+type SimulatedClock struct {
+	start time.Time
+	// speed is the factor by which the clock runs faster than the wall
+	// clock, startedAt is the wall time at which it started.
+	speed     float64
+	startedAt time.Time
+	// step is the duration by which the clock advances with every call to
+	// Now, n is the number of calls. If set, the wall clock is not used.
+	step time.Duration
+	n    int64
+
+	last time.Time
+}
+
+// NewSimulatedClock creates a clock starting at start. If step is greater
+// than 0, the clock advances by step with every call to Now, otherwise it
+// runs speed times as fast as the wall clock.
+func NewSimulatedClock(start time.Time, speed float64, step time.Duration) *SimulatedClock {
+	return &SimulatedClock{
+		start:     start,
+		speed:     speed,
+		startedAt: time.Now(),
+		step:      step,
+	}
+}
+
+// Now returns the current time of the clock.
+func (c *SimulatedClock) Now() time.Time {
+	if c.step > 0 {
+		c.last = c.start.Add(time.Duration(c.n) * c.step)
+		c.n++
+	} else {
+		c.last = c.start.Add(time.Duration(float64(time.Since(c.startedAt)) * c.speed))
+	}
+	return c.last
+}
+
+// Last returns the time last returned by Now.
+func (c *SimulatedClock) Last() time.Time {
+	return c.last
+}
+
+// Resume continues the clock after the given time, which was returned by
+// Last before a restart.
+func (c *SimulatedClock) Resume(last time.Time) {
+	c.start, c.last = last, last
+	c.startedAt = time.Now()
+	c.n = 1
+}
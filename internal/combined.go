@@ -15,38 +15,55 @@
 package internal
 
 import (
+	"maps"
 	"math/rand"
-	"strconv"
 
 	"github.com/conduitio/conduit-commons/opencdc"
 )
 
 // Combine combines multiple record generators into one. It will randomly
-// select one of the generators to generate the next record.
-func Combine(generators ...RecordGenerator) RecordGenerator {
+// select one of the generators to generate the next record, drawn from rnd.
+func Combine(rnd *rand.Rand, generators ...RecordGenerator) RecordGenerator {
 	if len(generators) == 1 {
 		return generators[0]
 	}
 	return &combinedRecordGenerator{
+		rnd:        rnd,
 		generators: generators,
 	}
 }
 
 type combinedRecordGenerator struct {
+	rnd        *rand.Rand
 	generators []RecordGenerator
 }
 
 func (g *combinedRecordGenerator) Next() opencdc.Record {
-	i := rand.Intn(len(g.generators))
-	gen := g.generators[i]
-	rec := gen.Next()
-
-	// keep position unique
-	prefix := []byte(strconv.Itoa(i))
-	newPos := make([]byte, len(prefix)+len(rec.Position))
-	copy(newPos, prefix)
-	copy(newPos[len(prefix):], rec.Position)
-	rec.Position = newPos
-
-	return rec
+	return g.generators[g.rnd.Intn(len(g.generators))].Next()
+}
+
+func (g *combinedRecordGenerator) State() map[string]GeneratorState {
+	states := make(map[string]GeneratorState)
+	for _, gen := range g.generators {
+		maps.Copy(states, gen.State())
+	}
+	return states
+}
+
+func (g *combinedRecordGenerator) Restore(states map[string]GeneratorState) {
+	for _, gen := range g.generators {
+		gen.Restore(states)
+	}
+}
+
+func (g *combinedRecordGenerator) SetOperations(operations []opencdc.Operation) {
+	for _, gen := range g.generators {
+		gen.SetOperations(operations)
+	}
+}
+
+func (g *combinedRecordGenerator) SetEventTime(eventTime *EventTime) {
+	for _, gen := range g.generators {
+		gen.SetEventTime(eventTime)
+	}
 }
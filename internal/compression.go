@@ -0,0 +1,141 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Compression codecs.
+const (
+	CompressionNone   = "none"
+	CompressionGzip   = "gzip"
+	CompressionSnappy = "snappy"
+	CompressionZstd   = "zstd"
+	CompressionLZ4    = "lz4"
+)
+
+// MetadataCompression is the metadata key containing the codec used to
+// compress the payload.
+const MetadataCompression = "generator.compression"
+
+// WithCompression compresses the raw data in the payloads generated by gen
+// with the given codec. Structured data is left untouched. If mark is true,
+// the codec is stored in the record metadata.
+func WithCompression(gen RecordGenerator, codec string, mark bool) (RecordGenerator, error) {
+	if codec == "" || codec == CompressionNone {
+		return gen, nil
+	}
+	compress, err := compressor(codec)
+	if err != nil {
+		return nil, err
+	}
+	return &compressionRecordGenerator{
+		RecordGenerator: gen,
+		codec:           codec,
+		mark:            mark,
+		compress:        compress,
+	}, nil
+}
+
+type compressionRecordGenerator struct {
+	RecordGenerator
+	codec    string
+	mark     bool
+	compress func([]byte) ([]byte, error)
+
+	// last and lastCompressed cache the last compressed data, generators
+	// reading files return the same data in every record.
+	last, lastCompressed []byte
+}
+
+func (g *compressionRecordGenerator) Next() opencdc.Record {
+	rec := g.RecordGenerator.Next()
+	rec.Payload.Before = g.compressData(rec.Payload.Before)
+	rec.Payload.After = g.compressData(rec.Payload.After)
+	if g.mark {
+		rec.Metadata[MetadataCompression] = g.codec
+	}
+	return rec
+}
+
+func (g *compressionRecordGenerator) compressData(d opencdc.Data) opencdc.Data {
+	raw, ok := d.(opencdc.RawData)
+	if !ok || len(raw) == 0 {
+		return d
+	}
+	if len(raw) == len(g.last) && &raw[0] == &g.last[0] {
+		return opencdc.RawData(g.lastCompressed)
+	}
+
+	compressed, err := g.compress(raw)
+	if err != nil {
+		panic(fmt.Errorf("couldn't compress data with %s: %w", g.codec, err))
+	}
+	g.last, g.lastCompressed = raw, compressed
+	return opencdc.RawData(compressed)
+}
+
+// compressor returns a function compressing data with the codec. Snappy uses
+// the block format, LZ4 the frame format.
+func compressor(codec string) (func([]byte) ([]byte, error), error) {
+	switch codec {
+	case CompressionGzip:
+		return func(b []byte) ([]byte, error) {
+			var buf bytes.Buffer
+			w := gzip.NewWriter(&buf)
+			if _, err := w.Write(b); err != nil {
+				return nil, err
+			}
+			if err := w.Close(); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		}, nil
+	case CompressionSnappy:
+		return func(b []byte) ([]byte, error) {
+			return snappy.Encode(nil, b), nil
+		}, nil
+	case CompressionZstd:
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+		}
+		return func(b []byte) ([]byte, error) {
+			return enc.EncodeAll(b, nil), nil
+		}, nil
+	case CompressionLZ4:
+		return func(b []byte) ([]byte, error) {
+			var buf bytes.Buffer
+			w := lz4.NewWriter(&buf)
+			if _, err := w.Write(b); err != nil {
+				return nil, err
+			}
+			if err := w.Close(); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown compression codec %q", codec)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/goccy/go-json"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Encodings of raw data.
+const (
+	EncodingJSON    = "json"
+	EncodingCSV     = "csv"
+	EncodingXML     = "xml"
+	EncodingMsgpack = "msgpack"
+	EncodingCBOR    = "cbor"
+	EncodingAvro    = "avro"
+)
+
+// RawEncoding describes how generated fields are encoded into raw data.
+type RawEncoding struct {
+	// Type is one of the encoding constants, defaults to JSON.
+	Type string
+	// CSVDelimiter separates the values in CSV encoded data.
+	CSVDelimiter rune
+	// CSVHeader adds a header line with the field names to CSV encoded data.
+	CSVHeader bool
+	// AvroConfluent prefixes Avro encoded data with the Confluent wire format
+	// header, consisting of a magic byte and the schema ID.
+	AvroConfluent bool
+	// AvroSchemaID is the schema ID written in the Confluent wire format
+	// header. If 0, the schema is registered with the schema service under
+	// AvroSubject and the assigned ID is used.
+	AvroSchemaID int
+	AvroSubject  string
+}
+
+// encoder returns a function encoding data containing the fields.
+func (e RawEncoding) encoder(ctx context.Context, fields []Field) (func(opencdc.StructuredData) ([]byte, error), error) {
+	switch e.Type {
+	case EncodingCSV:
+		return func(data opencdc.StructuredData) ([]byte, error) {
+			return e.encodeCSV(fields, data)
+		}, nil
+	case EncodingXML:
+		return func(data opencdc.StructuredData) ([]byte, error) {
+			return encodeXML(fields, data)
+		}, nil
+	case EncodingMsgpack:
+		return func(data opencdc.StructuredData) ([]byte, error) {
+			return msgpack.Marshal(map[string]any(data))
+		}, nil
+	case EncodingCBOR:
+		return func(data opencdc.StructuredData) ([]byte, error) {
+			return cbor.Marshal(map[string]any(data))
+		}, nil
+	case EncodingAvro:
+		return e.avroEncoder(ctx, fields)
+	case EncodingJSON, "":
+		return func(data opencdc.StructuredData) ([]byte, error) {
+			return json.Marshal(data)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", e.Type)
+	}
+}
+
+func (e RawEncoding) encodeCSV(fields []Field, data opencdc.StructuredData) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if e.CSVDelimiter != 0 {
+		w.Comma = e.CSVDelimiter
+	}
+
+	row := make([]string, len(fields))
+	if e.CSVHeader {
+		for i, f := range fields {
+			row[i] = f.Name
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	for i, f := range fields {
+		row[i] = formatValue(data[f.Name])
+	}
+	if err := w.Write(row); err != nil {
+		return nil, err
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// encodeXML encodes the data as a record element containing an element per
+// field. Null values are encoded as empty elements.
+func encodeXML(fields []Field, data opencdc.StructuredData) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("<record>")
+	for _, f := range fields {
+		buf.WriteString("<" + f.Name + ">")
+		if err := xml.EscapeText(&buf, []byte(formatValue(data[f.Name]))); err != nil {
+			return nil, err
+		}
+		buf.WriteString("</" + f.Name + ">")
+	}
+	buf.WriteString("</record>")
+	return buf.Bytes(), nil
+}
+
+// IsXMLName reports whether name can be used as an XML element name, which
+// starts with a letter or underscore followed by letters, digits, hyphens,
+// underscores or periods. Colons are not allowed, as they separate namespace
+// prefixes.
+func IsXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// formatValue formats a generated value for text based encodings.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/goccy/go-json"
+)
+
+// CDC envelopes.
+const (
+	EnvelopeNone     = "none"
+	EnvelopeDebezium = "debezium"
+	EnvelopeMaxwell  = "maxwell"
+	EnvelopeCanal    = "canal"
+)
+
+// envelopeDatabase is the database name reported in envelopes.
+const envelopeDatabase = "generator"
+
+// WithEnvelope wraps the changes generated by gen into JSON documents in the
+// given CDC envelope format. The document is stored as raw data in
+// Payload.After, Payload.Before is cleared.
+func WithEnvelope(gen RecordGenerator, envelope string) RecordGenerator {
+	if envelope == "" || envelope == EnvelopeNone {
+		return gen
+	}
+	return &envelopeRecordGenerator{
+		RecordGenerator: gen,
+		envelope:        envelope,
+	}
+}
+
+type envelopeRecordGenerator struct {
+	RecordGenerator
+	envelope string
+	count    int
+}
+
+func (g *envelopeRecordGenerator) Next() opencdc.Record {
+	rec := g.RecordGenerator.Next()
+	g.count++
+
+	var doc any
+	switch g.envelope {
+	case EnvelopeDebezium:
+		doc = g.debezium(rec)
+	case EnvelopeMaxwell:
+		doc = g.maxwell(rec)
+	case EnvelopeCanal:
+		doc = g.canal(rec)
+	default:
+		panic(fmt.Errorf("unknown envelope %q", g.envelope))
+	}
+
+	bytes, err := json.Marshal(doc)
+	if err != nil {
+		panic(fmt.Errorf("couldn't serialize envelope: %w", err))
+	}
+	rec.Payload = opencdc.Change{After: opencdc.RawData(bytes)}
+	// the payload schema describes the data inside the envelope
+	delete(rec.Metadata, opencdc.MetadataPayloadSchemaSubject)
+	delete(rec.Metadata, opencdc.MetadataPayloadSchemaVersion)
+	return rec
+}
+
+func (g *envelopeRecordGenerator) debezium(rec opencdc.Record) map[string]any {
+	ts := createdAt(rec).UnixMilli()
+	op := map[opencdc.Operation]string{
+		opencdc.OperationCreate:   "c",
+		opencdc.OperationUpdate:   "u",
+		opencdc.OperationDelete:   "d",
+		opencdc.OperationSnapshot: "r",
+	}[rec.Operation]
+	snapshot := "false"
+	if rec.Operation == opencdc.OperationSnapshot {
+		snapshot = "true"
+	}
+	collection, _ := rec.Metadata.GetCollection()
+
+	return map[string]any{
+		"before": envelopeData(rec.Payload.Before),
+		"after":  envelopeData(rec.Payload.After),
+		"op":     op,
+		"ts_ms":  ts,
+		"source": map[string]any{
+			"connector": "generator",
+			"name":      "generator",
+			"ts_ms":     ts,
+			"snapshot":  snapshot,
+			"db":        envelopeDatabase,
+			"table":     collection,
+		},
+	}
+}
+
+func (g *envelopeRecordGenerator) maxwell(rec opencdc.Record) map[string]any {
+	typ := map[opencdc.Operation]string{
+		opencdc.OperationCreate:   "insert",
+		opencdc.OperationUpdate:   "update",
+		opencdc.OperationDelete:   "delete",
+		opencdc.OperationSnapshot: "bootstrap-insert",
+	}[rec.Operation]
+	collection, _ := rec.Metadata.GetCollection()
+
+	doc := map[string]any{
+		"database": envelopeDatabase,
+		"table":    collection,
+		"type":     typ,
+		"ts":       createdAt(rec).Unix(),
+		"xid":      g.count,
+		"commit":   true,
+	}
+	switch rec.Operation {
+	case opencdc.OperationDelete:
+		doc["data"] = envelopeData(rec.Payload.Before)
+	case opencdc.OperationUpdate:
+		doc["data"] = envelopeData(rec.Payload.After)
+		doc["old"] = changedFields(rec.Payload.Before, rec.Payload.After)
+	default:
+		doc["data"] = envelopeData(rec.Payload.After)
+	}
+	return doc
+}
+
+func (g *envelopeRecordGenerator) canal(rec opencdc.Record) map[string]any {
+	typ := map[opencdc.Operation]string{
+		opencdc.OperationCreate:   "INSERT",
+		opencdc.OperationUpdate:   "UPDATE",
+		opencdc.OperationDelete:   "DELETE",
+		opencdc.OperationSnapshot: "INSERT",
+	}[rec.Operation]
+	collection, _ := rec.Metadata.GetCollection()
+	ts := createdAt(rec).UnixMilli()
+
+	var pkNames []string
+	if key, ok := rec.Key.(opencdc.StructuredData); ok {
+		pkNames = slices.Sorted(maps.Keys(key))
+	}
+
+	doc := map[string]any{
+		"id":       g.count,
+		"database": envelopeDatabase,
+		"table":    collection,
+		"type":     typ,
+		"es":       ts,
+		"ts":       ts,
+		"isDdl":    false,
+		"pkNames":  pkNames,
+		"old":      nil,
+	}
+	switch rec.Operation {
+	case opencdc.OperationDelete:
+		doc["data"] = []any{envelopeData(rec.Payload.Before)}
+	case opencdc.OperationUpdate:
+		doc["data"] = []any{envelopeData(rec.Payload.After)}
+		doc["old"] = []any{changedFields(rec.Payload.Before, rec.Payload.After)}
+	default:
+		doc["data"] = []any{envelopeData(rec.Payload.After)}
+	}
+	return doc
+}
+
+func createdAt(rec opencdc.Record) time.Time {
+	t, err := rec.Metadata.GetCreatedAt()
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
+
+// envelopeData converts the data into a value that can be embedded in a JSON
+// document. Raw data containing JSON is embedded as is, other raw data is
+// embedded as a string if it's valid UTF-8, otherwise it's base64 encoded.
+func envelopeData(d opencdc.Data) any {
+	switch d := d.(type) {
+	case nil:
+		return nil
+	case opencdc.StructuredData:
+		return map[string]any(d)
+	default:
+		raw := d.Bytes()
+		switch {
+		case json.Valid(raw):
+			return json.RawMessage(raw)
+		case utf8.Valid(raw):
+			return string(raw)
+		default:
+			return raw
+		}
+	}
+}
+
+// changedFields returns the fields in before that differ in after. If the
+// data is not structured, before is returned as a whole.
+func changedFields(before, after opencdc.Data) any {
+	b, ok1 := before.(opencdc.StructuredData)
+	a, ok2 := after.(opencdc.StructuredData)
+	if !ok1 || !ok2 {
+		return envelopeData(before)
+	}
+	old := make(map[string]any)
+	for k, v := range b {
+		if !reflect.DeepEqual(v, a[k]) {
+			old[k] = v
+		}
+	}
+	return old
+}
@@ -0,0 +1,170 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Event time models.
+const (
+	EventTimeMonotonic = "monotonic"
+	EventTimeBounded   = "bounded"
+)
+
+// Lateness distributions.
+const (
+	LatenessFixed       = "fixed"
+	LatenessUniform     = "uniform"
+	LatenessExponential = "exponential"
+)
+
+// EventTime derives the event time of records from the time they are
+// generated. The zero value returns the current time.
+type EventTime struct {
+	// Clock provides the time at which records are generated, if nil the
+	// wall clock is used.
+	Clock *SimulatedClock
+	// Rand is the source of random event times, it's required if the
+	// event times are not monotonic or if there are late events.
+	Rand *rand.Rand
+
+	// Model is the event time model. With EventTimeMonotonic event times
+	// never decrease, with EventTimeBounded they lag behind the generation
+	// time by up to MaxOutOfOrderness.
+	Model             string
+	MaxOutOfOrderness time.Duration
+	// LateRatio is the ratio of late events, which lag behind by more than
+	// MaxOutOfOrderness. Lateness is the additional lag of late events.
+	LateRatio float64
+	Lateness  Lateness
+	// Skew is added to all event times.
+	Skew time.Duration
+
+	// last is the latest event time of events that are not late.
+	last time.Time
+}
+
+// Now returns the time at which a record is generated.
+func (e *EventTime) Now() time.Time {
+	if e.Clock != nil {
+		return e.Clock.Now()
+	}
+	return time.Now()
+}
+
+// Next returns the event time of a record generated at now.
+func (e *EventTime) Next(now time.Time) time.Time {
+	t := now.Add(e.Skew)
+	if e.LateRatio > 0 && e.Rand.Float64() < e.LateRatio {
+		return t.Add(-e.MaxOutOfOrderness - e.Lateness.Random(e.Rand))
+	}
+
+	switch e.Model {
+	case EventTimeBounded:
+		if e.MaxOutOfOrderness > 0 {
+			t = t.Add(-time.Duration(e.Rand.Int63n(int64(e.MaxOutOfOrderness) + 1)))
+		}
+	default:
+		if t.Before(e.last) {
+			t = e.last
+		}
+	}
+	e.last = t
+	return t
+}
+
+// Lateness is a distribution of durations.
+type Lateness struct {
+	Distribution string
+	// Min is the fixed duration or the lower bound of the uniform
+	// distribution, Max is the upper bound of the uniform distribution and
+	// Mean the mean of the exponential distribution.
+	Min, Max, Mean time.Duration
+}
+
+// ParseLateness parses a lateness distribution, which is either a fixed
+// duration (e.g. "5m"), a uniform distribution (e.g. "uniform(1m,10m)") or an
+// exponential distribution with a mean (e.g. "exponential(5m)").
+func ParseLateness(s string) (Lateness, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Lateness{}, nil
+	}
+
+	name, params, ok := strings.Cut(s, "(")
+	if !ok {
+		d, err := parseNonNegativeDuration(s)
+		if err != nil {
+			return Lateness{}, err
+		}
+		return Lateness{Distribution: LatenessFixed, Min: d}, nil
+	}
+	if !strings.HasSuffix(params, ")") {
+		return Lateness{}, fmt.Errorf("missing closing parenthesis in %q", s)
+	}
+	args := strings.Split(strings.TrimSuffix(params, ")"), ",")
+
+	switch name = strings.ToLower(strings.TrimSpace(name)); name {
+	case LatenessUniform:
+		if len(args) != 2 {
+			return Lateness{}, fmt.Errorf("expected 2 parameters in %q", s)
+		}
+		lo, err1 := parseNonNegativeDuration(args[0])
+		hi, err2 := parseNonNegativeDuration(args[1])
+		if err := errors.Join(err1, err2); err != nil {
+			return Lateness{}, err
+		}
+		if lo > hi {
+			return Lateness{}, fmt.Errorf("min lateness %v is greater than max lateness %v", lo, hi)
+		}
+		return Lateness{Distribution: LatenessUniform, Min: lo, Max: hi}, nil
+	case LatenessExponential:
+		if len(args) != 1 {
+			return Lateness{}, fmt.Errorf("expected 1 parameter in %q", s)
+		}
+		mean, err := parseNonNegativeDuration(args[0])
+		if err != nil {
+			return Lateness{}, err
+		}
+		return Lateness{Distribution: LatenessExponential, Mean: mean}, nil
+	default:
+		return Lateness{}, fmt.Errorf("unknown lateness distribution %q", name)
+	}
+}
+
+func parseNonNegativeDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// Random returns a duration drawn from the distribution.
+func (l Lateness) Random(rnd *rand.Rand) time.Duration {
+	switch l.Distribution {
+	case LatenessUniform:
+		return l.Min + time.Duration(rnd.Int63n(int64(l.Max-l.Min)+1))
+	case LatenessExponential:
+		return time.Duration(rnd.ExpFloat64() * float64(l.Mean))
+	default:
+		return l.Min
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Schema evolution operations.
+const (
+	EvolutionAdd    = "add"
+	EvolutionDrop   = "drop"
+	EvolutionWiden  = "widen"
+	EvolutionRename = "rename"
+)
+
+// Evolution describes how the fields of generated data change over time. The
+// next step is applied after Records records or after Interval has passed
+// since the last step, whichever comes first.
+type Evolution struct {
+	Records  int
+	Interval time.Duration
+	Steps    []EvolutionStep
+}
+
+// EvolutionStep is a single change of the generated fields. It is parsed from
+// one of the following forms:
+//   - add:<field>:<type>
+//   - drop:<field>
+//   - widen:<field>:<type>
+//   - rename:<field>:<new name>
+type EvolutionStep struct {
+	Op    string
+	Field string
+	// Arg is the type for add and widen, and the new name for rename.
+	Arg string
+}
+
+// ParseEvolutionSteps parses the steps in the form described in
+// EvolutionStep.
+func ParseEvolutionSteps(raw []string) ([]EvolutionStep, error) {
+	steps := make([]EvolutionStep, 0, len(raw))
+	var errs []error
+	for _, r := range raw {
+		s, err := ParseEvolutionStep(r)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		steps = append(steps, s)
+	}
+	return steps, errors.Join(errs...)
+}
+
+// ParseEvolutionStep parses a step in the form described in EvolutionStep.
+func ParseEvolutionStep(raw string) (EvolutionStep, error) {
+	parts := strings.SplitN(strings.TrimSpace(raw), ":", 3)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	s := EvolutionStep{Op: strings.ToLower(parts[0])}
+	want := 3
+	switch s.Op {
+	case EvolutionAdd, EvolutionWiden, EvolutionRename:
+	case EvolutionDrop:
+		want = 2
+	default:
+		return EvolutionStep{}, fmt.Errorf("unknown schema evolution step %q", raw)
+	}
+	if len(parts) != want || slices.Contains(parts, "") {
+		return EvolutionStep{}, fmt.Errorf("invalid schema evolution step %q", raw)
+	}
+	s.Field = parts[1]
+	if want == 3 {
+		s.Arg = parts[2]
+	}
+	return s, nil
+}
+
+// String returns the step in the form described in EvolutionStep.
+func (s EvolutionStep) String() string {
+	if s.Arg == "" {
+		return s.Op + ":" + s.Field
+	}
+	return s.Op + ":" + s.Field + ":" + s.Arg
+}
+
+// Validate checks that all steps can be applied in order to the fields.
+func (e Evolution) Validate(fields []Field) error {
+	var err error
+	for _, s := range e.Steps {
+		fields, err = s.Apply(fields)
+		if err != nil {
+			return fmt.Errorf("schema evolution step %q: %w", s, err)
+		}
+	}
+	return nil
+}
+
+// Apply returns the fields after applying the step. The passed fields are not
+// modified.
+func (s EvolutionStep) Apply(fields []Field) ([]Field, error) {
+	i := slices.IndexFunc(fields, func(f Field) bool { return f.Name == s.Field })
+	if s.Op == EvolutionAdd {
+		if i >= 0 {
+			return nil, fmt.Errorf("field %q already exists", s.Field)
+		}
+		f, err := ParseField(s.Field, s.Arg)
+		if err != nil {
+			return nil, err
+		}
+		return sortFields(append(slices.Clone(fields), f)), nil
+	}
+	if i < 0 {
+		return nil, fmt.Errorf("field %q does not exist", s.Field)
+	}
+
+	switch s.Op {
+	case EvolutionDrop:
+		return slices.Delete(slices.Clone(fields), i, i+1), nil
+	case EvolutionWiden:
+		f, err := fields[i].widen(strings.ToLower(s.Arg))
+		if err != nil {
+			return nil, err
+		}
+		fields = slices.Clone(fields)
+		fields[i] = f
+		return fields, nil
+	case EvolutionRename:
+		if slices.ContainsFunc(fields, func(f Field) bool { return f.Name == s.Arg }) {
+			return nil, fmt.Errorf("field %q already exists", s.Arg)
+		}
+		fields = slices.Clone(fields)
+		fields[i].Name = s.Arg
+		return sortFields(fields), nil
+	default:
+		return nil, fmt.Errorf("unknown schema evolution step %q", s.Op)
+	}
+}
+
+// widen returns a copy of the field with a wider type. Integers can be widened
+// to floats, all types can be widened to strings. Configured values are
+// converted to the new type.
+func (f Field) widen(typ string) (Field, error) {
+	switch {
+	case typ == "float" && f.Type == "int":
+	case typ == "string" && f.Type != "string":
+		f.Min, f.Max = nil, nil
+	default:
+		return Field{}, fmt.Errorf("can't widen type of field %q from %q to %q", f.Name, f.Type, typ)
+	}
+
+	convert := func(values []any) []any {
+		if values == nil {
+			return nil
+		}
+		out := make([]any, len(values))
+		for i, v := range values {
+			out[i] = widenValue(v, typ)
+		}
+		return out
+	}
+	f.Type = typ
+	f.Values = convert(f.Values)
+	f.pool = convert(f.pool)
+	return f, nil
+}
+
+func widenValue(v any, typ string) any {
+	switch v := v.(type) {
+	case int:
+		if typ == "float" {
+			return float64(v)
+		}
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
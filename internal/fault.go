@@ -0,0 +1,177 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+)
+
+const (
+	FaultTransient = "transient"
+	FaultPermanent = "permanent"
+)
+
+// ErrInjectedFault is the error wrapped by all injected faults.
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultError is an injected error.
+type FaultError struct {
+	// Kind is the kind of the fault (transient, permanent).
+	Kind string
+	// Reason describes what triggered the fault.
+	Reason string
+}
+
+func (e *FaultError) Error() string {
+	return fmt.Sprintf("%s %v: %s", e.Kind, ErrInjectedFault, e.Reason)
+}
+
+// Unwrap returns ErrInjectedFault and, for transient faults,
+// sdk.ErrBackoffRetry, so the SDK retries reading after a backoff instead of
+// stopping the pipeline.
+func (e *FaultError) Unwrap() []error {
+	if e.Transient() {
+		return []error{ErrInjectedFault, sdk.ErrBackoffRetry}
+	}
+	return []error{ErrInjectedFault}
+}
+
+// Transient returns true if the operation can succeed when it's retried.
+func (e *FaultError) Transient() bool {
+	return e.Kind != FaultPermanent
+}
+
+// FaultState is the state of a FaultInjector, which is stored in positions so
+// that faults are not injected again after a restart.
+type FaultState struct {
+	// AfterRecordsDone is set once the fault after AfterRecords records was
+	// scheduled.
+	AfterRecordsDone bool `json:"afterRecordsDone,omitempty"`
+	// Failed is the reason of a permanent fault, after which all reads fail.
+	Failed string `json:"failed,omitempty"`
+}
+
+// FaultInjector decides when reading a record fails.
+type FaultInjector struct {
+	// AfterRecords is the number of records after which a fault is injected,
+	// 0 means never.
+	AfterRecords int
+	// Probability is the probability with which a fault is injected when
+	// reading a record.
+	Probability float64
+	// At are the times after opening at which a fault is injected.
+	At []time.Duration
+	// Kind is the kind of injected faults. After a permanent fault all
+	// subsequent reads fail.
+	Kind string
+	// Rand is the source of the random faults injected with Probability.
+	Rand *rand.Rand
+
+	// afterRecordsDone is set once the fault after AfterRecords records was
+	// scheduled, at is the index of the next fault in At.
+	afterRecordsDone bool
+	at               int
+	// next is the fault injected in the next read, failed is the permanent
+	// fault injected in all reads.
+	next   *FaultError
+	failed *FaultError
+}
+
+// Prepare decides if reading the next record fails, after count records were
+// read. The fault is decided in advance, so that the state stored in the
+// position of the last record already contains it.
+func (f *FaultInjector) Prepare(count int) {
+	if f.next != nil || f.failed != nil {
+		return
+	}
+	switch {
+	case f.AfterRecords > 0 && count >= f.AfterRecords && !f.afterRecordsDone:
+		f.next = f.fault(fmt.Sprintf("failed after %d records", count))
+		f.afterRecordsDone = true
+	case f.Probability > 0 && f.Rand.Float64() < f.Probability:
+		f.next = f.fault(fmt.Sprintf("failed with probability %v", f.Probability))
+	}
+}
+
+// Check returns a FaultError if reading the next record fails. count is the
+// number of records read so far and elapsed the time since opening.
+func (f *FaultInjector) Check(count int, elapsed time.Duration) error {
+	if f.failed != nil {
+		return f.failed
+	}
+
+	err := f.next
+	f.next = nil
+	if err == nil && f.at < len(f.At) && elapsed >= f.At[f.at] {
+		err = f.fault(fmt.Sprintf("failed at scheduled time %v", f.At[f.at]))
+		// skip all times that passed, so only one fault is injected
+		for f.at < len(f.At) && elapsed >= f.At[f.at] {
+			f.at++
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	if !err.Transient() {
+		f.failed = err
+	}
+	// the retried read can fail again
+	f.Prepare(count)
+	return err
+}
+
+// Pending returns true if reading the next record fails.
+func (f *FaultInjector) Pending() bool {
+	return f.next != nil || f.failed != nil
+}
+
+// Remaining returns the number of records that can be read before the fault
+// after AfterRecords records, after count records were read. It returns -1
+// if no such fault is coming.
+func (f *FaultInjector) Remaining(count int) int {
+	if f.AfterRecords == 0 || f.afterRecordsDone {
+		return -1
+	}
+	return max(f.AfterRecords-count, 0)
+}
+
+// State returns the state to store in the position of the last read record.
+func (f *FaultInjector) State() FaultState {
+	st := FaultState{AfterRecordsDone: f.afterRecordsDone}
+	switch {
+	case f.failed != nil:
+		st.Failed = f.failed.Reason
+	case f.next != nil && !f.next.Transient():
+		st.Failed = f.next.Reason
+	}
+	return st
+}
+
+// Restore restores the state returned by State.
+func (f *FaultInjector) Restore(st FaultState) {
+	f.afterRecordsDone = st.AfterRecordsDone
+	if st.Failed != "" {
+		f.failed = &FaultError{Kind: FaultPermanent, Reason: st.Failed}
+	}
+}
+
+func (f *FaultInjector) fault(reason string) *FaultError {
+	return &FaultError{Kind: f.Kind, Reason: reason}
+}
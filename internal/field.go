@@ -0,0 +1,299 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field describes a generated field. It is parsed from a field spec, which is
+// a type optionally followed by a list of parameters in parentheses, e.g.
+// `int(min=1,max=100,null=0.1)` or `string(values=new|paid|shipped)`.
+//
+// Supported parameters are:
+//   - min, max: bounds of generated int and float values.
+//   - null: ratio of generated null values (between 0 and 1).
+//   - values: values separated by "|", one of them is chosen randomly.
+//   - cardinality: number of distinct values generated.
+type Field struct {
+	Name string
+	Type string
+
+	Min, Max    *float64
+	NullRatio   float64
+	Values      []any
+	Cardinality int
+
+	// pool contains the distinct values if Cardinality is set.
+	pool []any
+}
+
+// ParseField parses the field spec of a field with the given name.
+func ParseField(name, spec string) (Field, error) {
+	f := Field{Name: name}
+
+	spec = strings.TrimSpace(spec)
+	typ, params, hasParams := strings.Cut(spec, "(")
+	f.Type = strings.ToLower(strings.TrimSpace(typ))
+	if !slices.Contains(KnownTypes, f.Type) {
+		return Field{}, fmt.Errorf("unknown data type %q", f.Type)
+	}
+	if !hasParams {
+		return f, nil
+	}
+	if !strings.HasSuffix(params, ")") {
+		return Field{}, fmt.Errorf("missing closing parenthesis in %q", spec)
+	}
+
+	var errs []error
+	for _, param := range strings.Split(strings.TrimSuffix(params, ")"), ",") {
+		if strings.TrimSpace(param) == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(param, "=")
+		if err := f.setParam(strings.TrimSpace(k), strings.TrimSpace(v)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return Field{}, err
+	}
+	if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+		return Field{}, fmt.Errorf("min %v is greater than max %v", *f.Min, *f.Max)
+	}
+	if f.Type == "int" && f.Min != nil && f.Max != nil && math.Ceil(*f.Min) > math.Floor(*f.Max) {
+		return Field{}, fmt.Errorf("no integer between min %v and max %v", *f.Min, *f.Max)
+	}
+
+	return f, nil
+}
+
+// fillPool draws the distinct values of a field with a cardinality, unless
+// they were drawn already.
+func (f *Field) fillPool(rnd *rand.Rand) {
+	if f.Cardinality == 0 || len(f.Values) > 0 || f.Type == "time" || len(f.pool) > 0 {
+		return
+	}
+	seen := make(map[any]bool, f.Cardinality)
+	// try a bounded number of times, the value range could be smaller than
+	// the cardinality (e.g. booleans)
+	for i := 0; i < f.Cardinality*10 && len(f.pool) < f.Cardinality; i++ {
+		v := f.randomValue(rnd, time.Time{})
+		if !seen[v] {
+			seen[v] = true
+			f.pool = append(f.pool, v)
+		}
+	}
+}
+
+// ParseFields parses the field specs in the map and returns the fields sorted
+// by name.
+func ParseFields(specs map[string]string) ([]Field, error) {
+	fields := make([]Field, 0, len(specs))
+	var errs []error
+	for name, spec := range specs {
+		f, err := ParseField(name, spec)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %q: %w", name, err))
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return sortFields(fields), errors.Join(errs...)
+}
+
+func sortFields(fields []Field) []Field {
+	slices.SortFunc(fields, func(a, b Field) int { return strings.Compare(a.Name, b.Name) })
+	return fields
+}
+
+func (f *Field) setParam(key, value string) error {
+	switch key {
+	case "min", "max":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", key, value, err)
+		}
+		if key == "min" {
+			f.Min = &n
+		} else {
+			f.Max = &n
+		}
+	case "null":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || n < 0 || n > 1 {
+			return fmt.Errorf("invalid null ratio %q, expected a number between 0 and 1", value)
+		}
+		f.NullRatio = n
+	case "cardinality":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid cardinality %q, expected a positive integer", value)
+		}
+		f.Cardinality = n
+	case "values":
+		for _, raw := range strings.Split(value, "|") {
+			v, err := f.parseValue(raw)
+			if err != nil {
+				return fmt.Errorf("invalid value %q: %w", raw, err)
+			}
+			f.Values = append(f.Values, v)
+		}
+	default:
+		return fmt.Errorf("unknown parameter %q", key)
+	}
+	return nil
+}
+
+func (f *Field) parseValue(raw string) (any, error) {
+	switch f.Type {
+	case "int":
+		return strconv.Atoi(raw)
+	case "float":
+		return strconv.ParseFloat(raw, 64)
+	case "bool":
+		return strconv.ParseBool(raw)
+	case "time":
+		return time.Parse(time.RFC3339Nano, raw)
+	case "duration":
+		return time.ParseDuration(raw)
+	default:
+		return raw, nil
+	}
+}
+
+// String returns the field spec.
+func (f Field) String() string {
+	var params []string
+	if f.Min != nil {
+		params = append(params, "min="+strconv.FormatFloat(*f.Min, 'g', -1, 64))
+	}
+	if f.Max != nil {
+		params = append(params, "max="+strconv.FormatFloat(*f.Max, 'g', -1, 64))
+	}
+	if f.NullRatio > 0 {
+		params = append(params, "null="+strconv.FormatFloat(f.NullRatio, 'g', -1, 64))
+	}
+	if len(f.Values) > 0 {
+		values := make([]string, len(f.Values))
+		for i, v := range f.Values {
+			switch v := v.(type) {
+			case time.Time:
+				values[i] = v.Format(time.RFC3339Nano)
+			default:
+				values[i] = fmt.Sprint(v)
+			}
+		}
+		params = append(params, "values="+strings.Join(values, "|"))
+	}
+	if f.Cardinality > 0 {
+		params = append(params, "cardinality="+strconv.Itoa(f.Cardinality))
+	}
+	if len(params) == 0 {
+		return f.Type
+	}
+	return f.Type + "(" + strings.Join(params, ",") + ")"
+}
+
+// Random returns a random value for the field. Times are set to now.
+func (f *Field) Random(rnd *rand.Rand, now time.Time) any {
+	switch {
+	case f.NullRatio > 0 && rnd.Float64() < f.NullRatio:
+		return nil
+	case len(f.Values) > 0:
+		return f.Values[rnd.Intn(len(f.Values))]
+	case len(f.pool) > 0:
+		return f.pool[rnd.Intn(len(f.pool))]
+	default:
+		return f.randomValue(rnd, now)
+	}
+}
+
+func (f *Field) randomValue(rnd *rand.Rand, now time.Time) any {
+	switch f.Type {
+	case "int":
+		if f.Min == nil && f.Max == nil {
+			return rnd.Int()
+		}
+		lo, hi := f.bounds(0, math.MaxInt32)
+		return randomInt(rnd, lo, hi)
+	case "float":
+		lo, hi := f.bounds(0, 1)
+		return lo + rnd.Float64()*(hi-lo)
+	case "string":
+		return randomWord(rnd)
+	case "time":
+		return now.UTC()
+	case "duration":
+		return time.Duration(rnd.Intn(1000)) * time.Second
+	case "bool":
+		return rnd.Int()%2 == 0
+	default:
+		panic(fmt.Errorf("field %q contains invalid type: %v", f.Name, f.Type))
+	}
+}
+
+// randomInt returns a random integer between lo and hi, both inclusive. The
+// bounds are clamped to the range of int64, so the range can be wider than
+// MaxInt64.
+func randomInt(rnd *rand.Rand, lo, hi float64) int {
+	a, b := clampInt64(math.Ceil(lo)), clampInt64(math.Floor(hi))
+	// the difference can overflow int64, but not uint64
+	span := uint64(b) - uint64(a)
+	var n uint64
+	switch {
+	case span < math.MaxInt64:
+		n = uint64(rnd.Int63n(int64(span) + 1))
+	case span == math.MaxUint64:
+		n = rnd.Uint64()
+	default:
+		// more than half of the values are in the range, so this returns
+		// after two draws on average
+		for n = rnd.Uint64(); n > span; n = rnd.Uint64() {
+		}
+	}
+	return int(a + int64(n))
+}
+
+func clampInt64(f float64) int64 {
+	switch {
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	case f <= math.MinInt64:
+		return math.MinInt64
+	default:
+		return int64(f)
+	}
+}
+
+func (f *Field) bounds(defaultMin, defaultMax float64) (float64, float64) {
+	lo, hi := defaultMin, defaultMax
+	switch {
+	case f.Min != nil && f.Max != nil:
+		lo, hi = *f.Min, *f.Max
+	case f.Min != nil:
+		lo, hi = *f.Min, *f.Min+defaultMax-defaultMin
+	case f.Max != nil:
+		lo, hi = *f.Max-defaultMax+defaultMin, *f.Max
+	}
+	return lo, hi
+}
@@ -15,66 +15,200 @@
 package internal
 
 import (
+	"context"
+	"encoding/binary"
 	"fmt"
 	"math/rand"
 	"os"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/conduitio/conduit-commons/opencdc"
-	"github.com/goccy/go-json"
+	"github.com/conduitio/conduit-connector-sdk/schema"
 )
 
-var KnownTypes = []string{"int", "string", "time", "bool", "duration"}
+var KnownTypes = []string{"int", "float", "string", "time", "bool", "duration"}
 
 // RecordGenerator is an interface for generating records.
 type RecordGenerator interface {
 	// Next generates the next record.
 	Next() opencdc.Record
+	// State returns the state of the generator for each collection it
+	// generates records for.
+	State() map[string]GeneratorState
+	// Restore restores the state returned by State. Collections missing in
+	// the state are left untouched.
+	Restore(map[string]GeneratorState)
+	// SetOperations changes the operations of generated records.
+	SetOperations([]opencdc.Operation)
+	// SetEventTime sets the model deriving the event time of generated
+	// records, which is used as the creation time and in time fields.
+	SetEventTime(*EventTime)
 }
 
 type baseRecordGenerator struct {
-	collection   string
-	operations   []opencdc.Operation
-	generateData func() opencdc.Data
+	// rnd is the source of random values.
+	rnd        *rand.Rand
+	collection string
+	operations []opencdc.Operation
+	// generateData generates the data of a record with the given event time.
+	generateData func(now time.Time) opencdc.Data
+	// payloadSchema is attached to generated records if set.
+	payloadSchema *schema.Schema
+	// keyFields are the fields of the generated structured data used as the
+	// record key. If empty, the key is a random word.
+	keyFields []string
+	// recordPayloadSize adds the size of the generated payload to the
+	// metadata.
+	recordPayloadSize bool
+	// evolution describes how the generated data changes over time,
+	// schemaVersions contains the data generator for each step of it.
+	evolution      Evolution
+	schemaVersions []func(now time.Time) opencdc.Data
 
-	count int
+	// eventTime derives the event time of records, if nil the event time is
+	// the current time.
+	eventTime *EventTime
+
+	count         int
+	schemaVersion int
+	// evolvedAt and evolvedTime are the record count and time at which the
+	// last evolution step was applied.
+	evolvedAt   int
+	evolvedTime time.Time
 }
 
 func (g *baseRecordGenerator) Next() opencdc.Record {
+	now := time.Now()
+	if g.eventTime != nil {
+		now = g.eventTime.Now()
+	}
 	g.count++
+	g.maybeEvolve(now)
+	if g.eventTime != nil {
+		now = g.eventTime.Next(now)
+	}
 
 	metadata := make(opencdc.Metadata)
-	metadata.SetCreatedAt(time.Now())
+	metadata.SetCreatedAt(now)
 	if g.collection != "" {
 		metadata.SetCollection(g.collection)
 	}
+	if g.payloadSchema != nil {
+		metadata.SetPayloadSchemaSubject(g.payloadSchema.Subject)
+		metadata.SetPayloadSchemaVersion(g.payloadSchema.Version)
+	}
 
 	rec := opencdc.Record{
-		Position:  opencdc.Position(strconv.Itoa(g.count)),
-		Operation: g.operations[rand.Intn(len(g.operations))],
+		Operation: g.operations[g.rnd.Intn(len(g.operations))],
 		Metadata:  metadata,
-		Key:       opencdc.RawData(randomWord()),
+		Key:       opencdc.RawData(randomWord(g.rnd)),
 	}
 
 	switch rec.Operation {
 	case opencdc.OperationSnapshot, opencdc.OperationCreate:
-		rec.Payload.After = g.generateData()
+		rec.Payload.After = g.generateData(now)
 	case opencdc.OperationUpdate:
-		rec.Payload.Before = g.generateData()
-		rec.Payload.After = g.generateData()
+		rec.Payload.Before = g.generateData(now)
+		rec.Payload.After = g.generateData(now)
 	case opencdc.OperationDelete:
-		rec.Payload.Before = g.generateData()
+		rec.Payload.Before = g.generateData(now)
+	}
+	if len(g.keyFields) > 0 {
+		rec.Key = g.extractKey(&rec.Payload)
+	}
+	if g.recordPayloadSize {
+		data := rec.Payload.After
+		if data == nil {
+			data = rec.Payload.Before
+		}
+		metadata[MetadataPayloadSize] = strconv.Itoa(len(data.Bytes()))
 	}
 
 	return rec
 }
 
+func (g *baseRecordGenerator) State() map[string]GeneratorState {
+	return map[string]GeneratorState{
+		g.collection: {
+			Count:         g.count,
+			SchemaVersion: g.schemaVersion,
+			EvolvedAt:     g.evolvedAt,
+		},
+	}
+}
+
+func (g *baseRecordGenerator) Restore(states map[string]GeneratorState) {
+	st, ok := states[g.collection]
+	if !ok {
+		return
+	}
+	g.count = st.Count
+	if st.SchemaVersion > 0 && st.SchemaVersion < len(g.schemaVersions) {
+		g.schemaVersion = st.SchemaVersion
+		g.generateData = g.schemaVersions[g.schemaVersion]
+	}
+	g.evolvedAt = st.EvolvedAt
+	g.evolvedTime = time.Time{} // restarts with the next record
+}
+
+func (g *baseRecordGenerator) SetOperations(operations []opencdc.Operation) {
+	g.operations = operations
+}
+
+func (g *baseRecordGenerator) SetEventTime(eventTime *EventTime) {
+	g.eventTime = eventTime
+}
+
+// maybeEvolve applies the next evolution step if enough records were generated
+// or enough time has passed since the last step. now is the time at which the
+// record is generated.
+func (g *baseRecordGenerator) maybeEvolve(now time.Time) {
+	if g.schemaVersion >= len(g.evolution.Steps) {
+		return
+	}
+	if g.evolvedTime.IsZero() {
+		g.evolvedTime = now
+	}
+
+	byCount := g.evolution.Records > 0 && g.count-g.evolvedAt > g.evolution.Records
+	byTime := g.evolution.Interval > 0 && now.Sub(g.evolvedTime) >= g.evolution.Interval
+	if !byCount && !byTime {
+		return
+	}
+	g.schemaVersion++
+	g.generateData = g.schemaVersions[g.schemaVersion]
+	g.evolvedAt = g.count - 1
+	g.evolvedTime = now
+}
+
+// extractKey builds a structured key out of the key fields in the payload. In
+// updates the key fields are copied from the data before the change to the
+// data after the change, so that both describe the same entity.
+func (g *baseRecordGenerator) extractKey(payload *opencdc.Change) opencdc.Data {
+	data, ok := payload.Before.(opencdc.StructuredData)
+	if !ok {
+		data, _ = payload.After.(opencdc.StructuredData)
+	}
+	after, _ := payload.After.(opencdc.StructuredData)
+
+	key := make(opencdc.StructuredData, len(g.keyFields))
+	for _, f := range g.keyFields {
+		key[f] = data[f]
+		if after != nil {
+			after[f] = data[f]
+		}
+	}
+	return key
+}
+
 // NewFileRecordGenerator creates a RecordGenerator that reads the contents of a
 // file at the given path. The file is read once and cached in memory. The
 // RecordGenerator will generate records with the contents of the file as the
-// payload data.
+// payload data. Random operations and keys are drawn from rnd.
 func NewFileRecordGenerator(
+	rnd *rand.Rand,
 	collection string,
 	operations []opencdc.Operation,
 	path string,
@@ -86,74 +220,161 @@ func NewFileRecordGenerator(
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 	return &baseRecordGenerator{
+		rnd:        rnd,
 		collection: collection,
 		operations: operations,
-		generateData: func() opencdc.Data {
+		generateData: func(time.Time) opencdc.Data {
 			return opencdc.RawData(bytes)
 		},
 	}, nil
 }
 
 // NewStructuredRecordGenerator creates a RecordGenerator that generates records
-// with structured data. The fields map should contain the field names and types
-// for the structured data. The types can be one of: int, string, time, bool.
+// with structured data. The fields map should contain the field names and field
+// specs for the structured data (see Field). The fields change over time as
+// described by evolution. If size is enabled, the data is padded to the target
+// size of its JSON representation. Random values are drawn from rnd.
 func NewStructuredRecordGenerator(
+	rnd *rand.Rand,
 	collection string,
 	operations []opencdc.Operation,
-	fields map[string]string,
+	specs map[string]string,
+	evolution Evolution,
+	size PayloadSize,
 ) (RecordGenerator, error) {
-	return &baseRecordGenerator{
-		collection: collection,
-		operations: operations,
-		generateData: func() opencdc.Data {
-			return randomStructuredData(fields)
-		},
-	}, nil
+	g, err := newFieldsRecordGenerator(rnd, collection, operations, specs, evolution, func(fields []Field) (func(time.Time) opencdc.Data, error) {
+		return func(now time.Time) opencdc.Data {
+			data := randomStructuredData(rnd, fields, now)
+			if size.Enabled() {
+				padStructuredData(rnd, data.(opencdc.StructuredData), size.Random(rnd))
+			}
+			return data
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	g.recordPayloadSize = size.Enabled()
+	return g, nil
 }
 
 // NewRawRecordGenerator creates a RecordGenerator that generates records with
-// raw data. The fields map should contain the field names and types for the raw
-// data. The types can be one of: int, string, time, bool.
+// raw data. The fields map should contain the field names and field specs for
+// the raw data (see Field). The fields change over time as described by
+// evolution and are encoded as described by encoding. If size is enabled, the
+// encoded data is padded to the target size. Random values are drawn from
+// rnd.
 func NewRawRecordGenerator(
+	ctx context.Context,
+	rnd *rand.Rand,
 	collection string,
 	operations []opencdc.Operation,
-	fields map[string]string,
+	specs map[string]string,
+	evolution Evolution,
+	encoding RawEncoding,
+	size PayloadSize,
 ) (RecordGenerator, error) {
+	g, err := newFieldsRecordGenerator(rnd, collection, operations, specs, evolution, func(fields []Field) (func(time.Time) opencdc.Data, error) {
+		encodedFields := fields
+		if size.Enabled() {
+			// the filler is part of the encoded data, e.g. in CSV headers
+			encodedFields = sortFields(append(slices.Clone(fields), Field{Name: FillerField, Type: "string"}))
+		}
+		encode, err := encoding.encoder(ctx, encodedFields)
+		if err != nil {
+			return nil, err
+		}
+		return func(now time.Time) opencdc.Data {
+			return randomRawData(rnd, fields, encode, size, now)
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	g.recordPayloadSize = size.Enabled()
+	return g, nil
+}
+
+// newFieldsRecordGenerator creates a generator producing data with the fields
+// parsed from specs. The data generator for each schema version is created in
+// advance by newGenerateData, so that invalid evolution steps are detected
+// early. The values of fields with a cardinality are drawn from rnd once for
+// all versions.
+func newFieldsRecordGenerator(
+	rnd *rand.Rand,
+	collection string,
+	operations []opencdc.Operation,
+	specs map[string]string,
+	evolution Evolution,
+	newGenerateData func([]Field) (func(time.Time) opencdc.Data, error),
+) (*baseRecordGenerator, error) {
+	fields, err := ParseFields(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]func(time.Time) opencdc.Data, 0, len(evolution.Steps)+1)
+	for i := 0; ; i++ {
+		for j := range fields {
+			fields[j].fillPool(rnd)
+		}
+		generateData, err := newGenerateData(fields)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, generateData)
+		if i == len(evolution.Steps) {
+			break
+		}
+		fields, err = evolution.Steps[i].Apply(fields)
+		if err != nil {
+			return nil, fmt.Errorf("schema evolution step %q: %w", evolution.Steps[i], err)
+		}
+	}
+
 	return &baseRecordGenerator{
-		collection: collection,
-		operations: operations,
-		generateData: func() opencdc.Data {
-			return randomRawData(fields)
-		},
+		rnd:            rnd,
+		collection:     collection,
+		operations:     operations,
+		generateData:   versions[0],
+		evolution:      evolution,
+		schemaVersions: versions,
 	}, nil
 }
 
-func randomStructuredData(fields map[string]string) opencdc.Data {
-	data := make(opencdc.StructuredData)
-	for field, typ := range fields {
-		switch typ {
-		case "int":
-			data[field] = rand.Int()
-		case "string":
-			data[field] = randomWord()
-		case "time":
-			data[field] = time.Now().UTC()
-		case "duration":
-			data[field] = time.Duration(rand.Intn(1000)) * time.Second
-		case "bool":
-			data[field] = rand.Int()%2 == 0
-		default:
-			panic(fmt.Errorf("field %q contains invalid type: %v", field, typ))
-		}
+func randomStructuredData(rnd *rand.Rand, fields []Field, now time.Time) opencdc.Data {
+	data := make(opencdc.StructuredData, len(fields))
+	for i := range fields {
+		data[fields[i].Name] = fields[i].Random(rnd, now)
 	}
 	return data
 }
 
-func randomRawData(fields map[string]string) opencdc.RawData {
-	data := randomStructuredData(fields)
-	bytes, err := json.Marshal(data)
+func randomRawData(
+	rnd *rand.Rand,
+	fields []Field,
+	encode func(opencdc.StructuredData) ([]byte, error),
+	size PayloadSize,
+	now time.Time,
+) opencdc.RawData {
+	data := randomStructuredData(rnd, fields, now).(opencdc.StructuredData)
+	var bytes []byte
+	var err error
+	if size.Enabled() {
+		bytes, err = padEncodedData(rnd, data, size.Random(rnd), encode)
+	} else {
+		bytes, err = encode(data)
+	}
 	if err != nil {
 		panic(fmt.Errorf("couldn't serialize data: %w", err))
 	}
 	return bytes
 }
+
+func randomBytes(rnd *rand.Rand, n int) []byte {
+	b := make([]byte, n+7)
+	for i := 0; i < n; i += 8 {
+		binary.LittleEndian.PutUint64(b[i:], rnd.Uint64())
+	}
+	return b[:n]
+}
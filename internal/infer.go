@@ -0,0 +1,306 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	// inferMaxEnumValues is the maximum number of distinct values of a field
+	// that are used as enum candidates.
+	inferMaxEnumValues = 10
+	// inferMaxDistinct is the maximum number of distinct values tracked per
+	// field, fields with more distinct values have no cardinality.
+	inferMaxDistinct = 10000
+)
+
+// InferFields reads the sample records in the file at the given path and
+// infers the fields of the records, including their types, value ranges,
+// cardinalities, null ratios and enum candidates. The file can contain JSON
+// objects separated by newlines (.jsonl, .ndjson, .json) or comma separated
+// values with a header row (.csv). Nested objects and arrays are skipped.
+func InferFields(path string) ([]Field, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	var stats *inferStats
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		stats, err = inferCSV(f)
+	case ".jsonl", ".ndjson", ".json":
+		stats, err = inferJSONL(f)
+	default:
+		return nil, fmt.Errorf("unsupported sample file extension %q, expected .jsonl, .ndjson, .json or .csv", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stats.rows == 0 {
+		return nil, errors.New("sample file contains no records")
+	}
+	return stats.inferredFields(), nil
+}
+
+type inferStats struct {
+	rows   int
+	fields map[string]*fieldStats
+	// order keeps the fields in the order they were first seen
+	order []string
+}
+
+type fieldStats struct {
+	name     string
+	count    int
+	types    map[string]int
+	min, max float64
+	distinct map[string]any
+	// overflow is set if the field has more than inferMaxDistinct values
+	overflow bool
+}
+
+func (s *inferStats) add(name string, typ string, val any) {
+	fs, ok := s.fields[name]
+	if !ok {
+		fs = &fieldStats{
+			name:     name,
+			types:    make(map[string]int),
+			distinct: make(map[string]any),
+			min:      math.Inf(1),
+			max:      math.Inf(-1),
+		}
+		s.fields[name] = fs
+		s.order = append(s.order, name)
+	}
+	if val == nil {
+		return
+	}
+
+	fs.count++
+	fs.types[typ]++
+	switch v := val.(type) {
+	case int:
+		fs.min, fs.max = min(fs.min, float64(v)), max(fs.max, float64(v))
+	case float64:
+		fs.min, fs.max = min(fs.min, v), max(fs.max, v)
+	}
+	if !fs.overflow {
+		fs.distinct[fmt.Sprint(val)] = val
+		if len(fs.distinct) > inferMaxDistinct {
+			fs.overflow = true
+			fs.distinct = nil
+		}
+	}
+}
+
+func (s *inferStats) inferredFields() []Field {
+	fields := make([]Field, 0, len(s.order))
+	for _, name := range s.order {
+		fs := s.fields[name]
+		f := Field{Name: name, Type: fs.typ()}
+		if nulls := s.rows - fs.count; nulls > 0 {
+			f.NullRatio = math.Round(float64(nulls)/float64(s.rows)*1000) / 1000
+		}
+		if f.Type == "int" || f.Type == "float" {
+			lo, hi := fs.min, fs.max
+			f.Min, f.Max = &lo, &hi
+		}
+
+		switch {
+		case fs.overflow || fs.count == 0 || f.Type == "bool" || f.Type == "time":
+			// no enum or cardinality
+		case len(fs.distinct) <= inferMaxEnumValues && fs.count >= 2*len(fs.distinct) && fs.enumCompatible():
+			f.Values = fs.enumValues(f.Type)
+		case len(fs.distinct) < fs.count && (f.Type == "string" || f.Type == "int"):
+			f.Cardinality = len(fs.distinct)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// typ returns the type that can represent all values of the field.
+func (fs *fieldStats) typ() string {
+	switch {
+	case len(fs.types) == 0:
+		return "string"
+	case len(fs.types) == 1:
+		for t := range fs.types {
+			return t
+		}
+	case len(fs.types) == 2 && fs.types["int"] > 0 && fs.types["float"] > 0:
+		return "float"
+	}
+	return "string"
+}
+
+// enumCompatible checks if the values can be represented in a field spec.
+func (fs *fieldStats) enumCompatible() bool {
+	for k := range fs.distinct {
+		if k == "" || strings.ContainsAny(k, "|,()") {
+			return false
+		}
+	}
+	return true
+}
+
+func (fs *fieldStats) enumValues(typ string) []any {
+	keys := make([]string, 0, len(fs.distinct))
+	for k := range fs.distinct {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	values := make([]any, len(keys))
+	for i, k := range keys {
+		v := fs.distinct[k]
+		if typ == "float" {
+			// ints and floats can be mixed in a float field
+			if n, ok := v.(int); ok {
+				v = float64(n)
+			}
+		}
+		if typ == "string" {
+			v = k
+		}
+		values[i] = v
+	}
+	return values
+}
+
+func inferJSONL(r io.Reader) (*inferStats, error) {
+	stats := &inferStats{fields: make(map[string]*fieldStats)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var rec map[string]any
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&rec); err != nil {
+			return nil, fmt.Errorf("failed to parse line %d: %w", line, err)
+		}
+
+		stats.rows++
+		for k, v := range rec {
+			typ, val, ok := inferJSONValue(v)
+			if ok {
+				stats.add(k, typ, val)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return stats, nil
+}
+
+func inferJSONValue(v any) (string, any, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil, true
+	case bool:
+		return "bool", v, true
+	case json.Number:
+		if n, err := strconv.Atoi(v.String()); err == nil {
+			return "int", n, true
+		}
+		n, err := v.Float64()
+		return "float", n, err == nil
+	case string:
+		typ, val := inferString(v)
+		return typ, val, true
+	default:
+		return "", nil, false // nested objects and arrays are not supported
+	}
+}
+
+func inferCSV(r io.Reader) (*inferStats, error) {
+	stats := &inferStats{fields: make(map[string]*fieldStats)}
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV record: %w", err)
+		}
+
+		stats.rows++
+		for i, raw := range rec {
+			if i >= len(header) {
+				break
+			}
+			if raw == "" {
+				stats.add(header[i], "", nil)
+				continue
+			}
+			typ, val := inferCSVValue(raw)
+			stats.add(header[i], typ, val)
+		}
+	}
+	return stats, nil
+}
+
+func inferCSVValue(raw string) (string, any) {
+	if b, err := strconv.ParseBool(raw); err == nil && !strings.ContainsAny(raw, "01") {
+		return "bool", b
+	}
+	if n, err := strconv.Atoi(raw); err == nil {
+		return "int", n
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		return "float", f
+	}
+	return inferString(raw)
+}
+
+// inferString detects times and durations in strings.
+func inferString(s string) (string, any) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return "time", t
+	}
+	if d, err := time.ParseDuration(s); err == nil && strings.ContainsAny(s, "hmsuµn") {
+		return "duration", d
+	}
+	return "string", s
+}
@@ -0,0 +1,111 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// latencyGrowth is the factor between the bounds of consecutive buckets
+	// of a latency histogram, which determines its precision.
+	latencyGrowth = 1.02
+	// latencyBuckets is the number of buckets of a latency histogram, the
+	// last bucket contains all latencies above about 2 hours.
+	latencyBuckets = 1200
+)
+
+// LatencyHistogram records latencies in buckets with exponentially growing
+// bounds, so that percentiles can be computed with constant memory. The
+// precision of percentiles is about 2%.
+type LatencyHistogram struct {
+	counts []int64
+	count  int64
+	max    time.Duration
+}
+
+// LatencyStats contains percentiles of recorded latencies.
+type LatencyStats struct {
+	Count         int64
+	P50, P95, P99 time.Duration
+	Max           time.Duration
+}
+
+// NewLatencyHistogram creates an empty histogram.
+func NewLatencyHistogram() *LatencyHistogram {
+	return &LatencyHistogram{counts: make([]int64, latencyBuckets)}
+}
+
+// Record adds a latency to the histogram.
+func (h *LatencyHistogram) Record(d time.Duration) {
+	h.counts[latencyBucket(d)]++
+	h.count++
+	h.max = max(h.max, d)
+}
+
+// Stats returns the percentiles of the recorded latencies.
+func (h *LatencyHistogram) Stats() LatencyStats {
+	return LatencyStats{
+		Count: h.count,
+		P50:   h.Percentile(0.5),
+		P95:   h.Percentile(0.95),
+		P99:   h.Percentile(0.99),
+		Max:   h.max,
+	}
+}
+
+// Percentile returns the latency below which the fraction p of the recorded
+// latencies fall, it's the upper bound of the bucket containing the
+// percentile.
+func (h *LatencyHistogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p * float64(h.count)))
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= max(rank, 1) {
+			return min(latencyBucketBound(i), h.max)
+		}
+	}
+	return h.max
+}
+
+// Reset removes all recorded latencies.
+func (h *LatencyHistogram) Reset() {
+	clear(h.counts)
+	h.count = 0
+	h.max = 0
+}
+
+// latencyBucket returns the index of the bucket containing d. Bucket 0
+// contains latencies below 1µs, bucket i latencies below 1µs*growth^i.
+func latencyBucket(d time.Duration) int {
+	if d < time.Microsecond {
+		return 0
+	}
+	i := 1 + int(math.Log(float64(d)/float64(time.Microsecond))/math.Log(latencyGrowth))
+	return min(i, latencyBuckets-1)
+}
+
+// latencyBucketBound returns the upper bound of the bucket.
+func latencyBucketBound(i int) time.Duration {
+	if i == latencyBuckets-1 {
+		return math.MaxInt64
+	}
+	return time.Duration(float64(time.Microsecond) * math.Pow(latencyGrowth, float64(i)))
+}
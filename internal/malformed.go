@@ -0,0 +1,231 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/goccy/go-json"
+)
+
+// Kinds of malformed payloads.
+const (
+	MalformedTruncated   = "truncated"
+	MalformedWrongTypes  = "wrongTypes"
+	MalformedInvalidUTF8 = "invalidUTF8"
+	MalformedOversized   = "oversized"
+	MalformedEmpty       = "empty"
+)
+
+// MalformedKinds contains all kinds of malformed payloads.
+var MalformedKinds = []string{
+	MalformedTruncated,
+	MalformedWrongTypes,
+	MalformedInvalidUTF8,
+	MalformedOversized,
+	MalformedEmpty,
+}
+
+// DefaultMalformedKinds contains the kinds of malformed payloads used if none
+// are configured. Oversized payloads are left out, as they are expensive to
+// generate and have to be enabled explicitly.
+var DefaultMalformedKinds = []string{
+	MalformedTruncated,
+	MalformedWrongTypes,
+	MalformedInvalidUTF8,
+	MalformedEmpty,
+}
+
+// MetadataMalformed is the metadata key containing the kind of malformation
+// of an intentionally malformed payload.
+const MetadataMalformed = "generator.malformed"
+
+// Malformed describes how often and how payloads are malformed.
+type Malformed struct {
+	// Probability is the probability with which a payload is malformed.
+	Probability float64
+	// Kinds are the kinds of malformations, one is chosen randomly.
+	Kinds []string
+	// OversizedSize is the size of oversized payloads in bytes.
+	OversizedSize int
+}
+
+// WithMalformed malforms the payloads generated by gen with the configured
+// probability. The payload after the change is malformed, or the payload
+// before the change if there is none after it. The kind of malformation is
+// stored in the record metadata. The payload schema is removed from malformed
+// records, as the payload doesn't match it anymore. Random decisions are drawn
+// from rnd.
+func WithMalformed(gen RecordGenerator, rnd *rand.Rand, malformed Malformed) RecordGenerator {
+	if malformed.Probability <= 0 {
+		return gen
+	}
+	if len(malformed.Kinds) == 0 {
+		malformed.Kinds = DefaultMalformedKinds
+	}
+	return &malformedRecordGenerator{
+		RecordGenerator: gen,
+		rnd:             rnd,
+		malformed:       malformed,
+	}
+}
+
+type malformedRecordGenerator struct {
+	RecordGenerator
+	rnd       *rand.Rand
+	malformed Malformed
+}
+
+func (g *malformedRecordGenerator) Next() opencdc.Record {
+	rec := g.RecordGenerator.Next()
+	if g.rnd.Float64() >= g.malformed.Probability {
+		return rec
+	}
+
+	data := &rec.Payload.After
+	if *data == nil {
+		data = &rec.Payload.Before
+	}
+	kind := g.malformed.Kinds[g.rnd.Intn(len(g.malformed.Kinds))]
+	*data, kind = g.malform(*data, kind)
+	rec.Metadata[MetadataMalformed] = kind
+	// the malformed payload doesn't match the schema anymore, encoding it
+	// with the schema would fail before the record reaches the pipeline
+	delete(rec.Metadata, opencdc.MetadataPayloadSchemaSubject)
+	delete(rec.Metadata, opencdc.MetadataPayloadSchemaVersion)
+	return rec
+}
+
+// malform returns a malformed copy of the data and the applied kind of
+// malformation. Wrong types can only be applied to structured data and raw
+// JSON objects, other data is truncated instead.
+func (g *malformedRecordGenerator) malform(d opencdc.Data, kind string) (opencdc.Data, string) {
+	if d == nil {
+		d = opencdc.RawData{}
+	}
+	switch kind {
+	case MalformedWrongTypes:
+		if sd, ok := d.(opencdc.StructuredData); ok {
+			return wrongTypes(g.rnd, sd), kind
+		}
+		var sd opencdc.StructuredData
+		if err := json.Unmarshal(d.Bytes(), &sd); err == nil && sd != nil {
+			return opencdc.RawData(wrongTypes(g.rnd, sd).Bytes()), kind
+		}
+		return truncate(g.rnd, d), MalformedTruncated
+	case MalformedInvalidUTF8:
+		return invalidUTF8(g.rnd, d), kind
+	case MalformedOversized:
+		return oversize(d, g.malformed.OversizedSize), kind
+	case MalformedEmpty:
+		if _, ok := d.(opencdc.StructuredData); ok {
+			return opencdc.StructuredData{}, kind
+		}
+		return opencdc.RawData{}, kind
+	default:
+		return truncate(g.rnd, d), MalformedTruncated
+	}
+}
+
+// truncate cuts off the serialized data at a random position.
+func truncate(rnd *rand.Rand, d opencdc.Data) opencdc.Data {
+	b := d.Bytes()
+	if len(b) <= 1 {
+		return opencdc.RawData{}
+	}
+	n := 1 + rnd.Intn(len(b)-1)
+	return opencdc.RawData(b[:n:n])
+}
+
+// wrongTypes returns a copy of the data with values of different types. The
+// fields are changed in sorted order, so the values only depend on rnd.
+func wrongTypes(rnd *rand.Rand, sd opencdc.StructuredData) opencdc.StructuredData {
+	out := make(opencdc.StructuredData, len(sd))
+	for _, k := range slices.Sorted(maps.Keys(sd)) {
+		out[k] = wrongType(rnd, sd[k])
+	}
+	return out
+}
+
+func wrongType(rnd *rand.Rand, v any) any {
+	switch v := v.(type) {
+	case string:
+		return rnd.Int()
+	case bool:
+		return fmt.Sprint(!v)
+	case time.Time:
+		return v.Unix()
+	case nil:
+		return rnd.Int()%2 == 0
+	case map[string]any, []any, opencdc.StructuredData:
+		return randomWord(rnd)
+	default:
+		// numbers and everything else
+		return fmt.Sprint(v)
+	}
+}
+
+// invalidUTF8 inserts an invalid UTF-8 sequence into the data. In structured
+// data the value of a random field is replaced.
+func invalidUTF8(rnd *rand.Rand, d opencdc.Data) opencdc.Data {
+	const invalid = "\xff\xfe"
+	if sd, ok := d.(opencdc.StructuredData); ok {
+		out := make(opencdc.StructuredData, len(sd)+1)
+		maps.Copy(out, sd)
+		field := FillerField
+		if n := rnd.Intn(len(sd) + 1); n < len(sd) {
+			field = slices.Sorted(maps.Keys(sd))[n]
+		}
+		value := invalid
+		if v := out[field]; v != nil {
+			value += fmt.Sprint(v)
+		}
+		out[field] = value
+		return out
+	}
+
+	b := d.Bytes()
+	pos := rnd.Intn(len(b) + 1)
+	out := make([]byte, 0, len(b)+len(invalid))
+	out = append(out, b[:pos]...)
+	out = append(out, invalid...)
+	out = append(out, b[pos:]...)
+	return opencdc.RawData(out)
+}
+
+// oversize pads the data to the given size. Structured data is padded in the
+// filler field.
+func oversize(d opencdc.Data, size int) opencdc.Data {
+	if sd, ok := d.(opencdc.StructuredData); ok {
+		out := make(opencdc.StructuredData, len(sd)+1)
+		maps.Copy(out, sd)
+		out[FillerField] = strings.Repeat("x", max(size-len(sd.Bytes()), 0))
+		return out
+	}
+
+	b := d.Bytes()
+	out := make([]byte, max(size, len(b)))
+	copy(out, b)
+	for i := len(b); i < len(out); i++ {
+		out[i] = 'x'
+	}
+	return opencdc.RawData(out)
+}
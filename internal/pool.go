@@ -0,0 +1,94 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"maps"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+// WithPool pre-generates size records with gen and cycles through them
+// instead of generating a new record each time, so the generator is not the
+// bottleneck when measuring the throughput of a pipeline. The metadata and
+// structured keys and payloads are copied for each record, so changing them
+// doesn't change other records. Raw keys and payloads are shared between the
+// records cycled from the same pooled record.
+func WithPool(gen RecordGenerator, size int) RecordGenerator {
+	if size <= 0 {
+		return gen
+	}
+	g := &poolRecordGenerator{
+		RecordGenerator: gen,
+		pool:            make([]opencdc.Record, size),
+	}
+	g.fill()
+	return g
+}
+
+type poolRecordGenerator struct {
+	RecordGenerator
+	pool []opencdc.Record
+	next int
+	// state is the state of the underlying generator before the pool was
+	// filled, so that the pool is generated from the same state after a
+	// restart.
+	state map[string]GeneratorState
+}
+
+func (g *poolRecordGenerator) Next() opencdc.Record {
+	rec := g.pool[g.next]
+	g.next = (g.next + 1) % len(g.pool)
+	rec.Metadata = maps.Clone(rec.Metadata)
+	rec.Key = cloneStructuredData(rec.Key)
+	rec.Payload.Before = cloneStructuredData(rec.Payload.Before)
+	rec.Payload.After = cloneStructuredData(rec.Payload.After)
+	return rec
+}
+
+func (g *poolRecordGenerator) State() map[string]GeneratorState {
+	return g.state
+}
+
+// Restore restores the state of the underlying generator and generates the
+// pool again from it.
+func (g *poolRecordGenerator) Restore(states map[string]GeneratorState) {
+	g.RecordGenerator.Restore(states)
+	g.fill()
+}
+
+// SetOperations sets the operations of the underlying generator and
+// generates the pool again, so the pooled records use the new operations.
+func (g *poolRecordGenerator) SetOperations(operations []opencdc.Operation) {
+	g.RecordGenerator.SetOperations(operations)
+	g.fill()
+}
+
+func (g *poolRecordGenerator) fill() {
+	g.state = g.RecordGenerator.State()
+	for i := range g.pool {
+		g.pool[i] = g.RecordGenerator.Next()
+	}
+	g.next = 0
+}
+
+// cloneStructuredData returns a copy of structured data, other data is
+// returned as is.
+func cloneStructuredData(d opencdc.Data) opencdc.Data {
+	if sd, ok := d.(opencdc.StructuredData); ok {
+		return sd.Clone()
+	}
+	return d
+}
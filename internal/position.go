@@ -0,0 +1,145 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/goccy/go-json"
+)
+
+// Position is the position of a generated record. Besides identifying the
+// record, it captures the state of the record generators, so that a restarted
+// generator can continue where it left off.
+type Position struct {
+	// Seq is the sequence number of the position, it increases with every
+	// emitted position.
+	Seq int64 `json:"seq,omitempty"`
+	// Collections contains the state of the record generator of each
+	// collection.
+	Collections map[string]GeneratorState `json:"collections"`
+	// RecordCount is the number of records generated in all collections.
+	RecordCount int `json:"recordCount,omitempty"`
+	// Phase is the index of the current phase of the scenario.
+	Phase int `json:"phase,omitempty"`
+	// PhaseCount is the number of records generated in the current phase.
+	PhaseCount int `json:"phaseCount,omitempty"`
+	// Redelivered is the number of duplicates emitted since the last
+	// generated record, it makes the positions of duplicates unique.
+	Redelivered int `json:"redelivered,omitempty"`
+	// Clock is the time of the simulated clock when the record was
+	// generated.
+	Clock *time.Time `json:"clock,omitempty"`
+	// Faults is the state of the fault injection, if faults were injected or
+	// scheduled.
+	Faults *FaultState `json:"faults,omitempty"`
+	// Recent contains the most recently generated records without their
+	// positions, they are replayed when the generator is restarted.
+	Recent []opencdc.Record `json:"recent,omitempty"`
+}
+
+// MetadataDuplicate is the metadata key set on records that are emitted more
+// than once.
+const MetadataDuplicate = "generator.duplicate"
+
+// GeneratorState is the state of the record generator of a single collection.
+type GeneratorState struct {
+	// Count is the number of records generated in the collection.
+	Count int `json:"count"`
+	// SchemaVersion is the number of schema evolution steps applied.
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+	// EvolvedAt is the record count at which the last schema evolution step
+	// was applied.
+	EvolvedAt int `json:"evolvedAt,omitempty"`
+	// Rows is the number of rows generated in a SQL table, it determines the
+	// next sequential primary key.
+	Rows int `json:"rows,omitempty"`
+}
+
+// ParsePosition parses a position created by Position.ToRecordPosition.
+func ParsePosition(pos opencdc.Position) (Position, error) {
+	var p struct {
+		Position
+		Recent []json.RawMessage `json:"recent"`
+	}
+	if err := json.Unmarshal(pos, &p); err != nil {
+		return Position{}, fmt.Errorf("failed to parse position: %w", err)
+	}
+	for _, raw := range p.Recent {
+		rec, err := parseRecentRecord(raw)
+		if err != nil {
+			return Position{}, fmt.Errorf("failed to parse position: %w", err)
+		}
+		p.Position.Recent = append(p.Position.Recent, rec)
+	}
+	return p.Position, nil
+}
+
+// parseRecentRecord parses a record stored in a position. Numbers in
+// structured data are parsed as json.Number, so they are serialized the same
+// way as in the original record.
+func parseRecentRecord(raw []byte) (opencdc.Record, error) {
+	var rec opencdc.Record
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return opencdc.Record{}, err
+	}
+	var data struct {
+		Key     json.RawMessage `json:"key"`
+		Payload struct {
+			Before json.RawMessage `json:"before"`
+			After  json.RawMessage `json:"after"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return opencdc.Record{}, err
+	}
+
+	var err error
+	if rec.Key, err = withNumbers(rec.Key, data.Key); err != nil {
+		return opencdc.Record{}, err
+	}
+	if rec.Payload.Before, err = withNumbers(rec.Payload.Before, data.Payload.Before); err != nil {
+		return opencdc.Record{}, err
+	}
+	if rec.Payload.After, err = withNumbers(rec.Payload.After, data.Payload.After); err != nil {
+		return opencdc.Record{}, err
+	}
+	return rec, nil
+}
+
+func withNumbers(d opencdc.Data, raw []byte) (opencdc.Data, error) {
+	if _, ok := d.(opencdc.StructuredData); !ok {
+		return d, nil
+	}
+	var sd opencdc.StructuredData
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&sd); err != nil {
+		return nil, fmt.Errorf("failed to parse structured data: %w", err)
+	}
+	return sd, nil
+}
+
+// ToRecordPosition serializes the position into a record position.
+func (p Position) ToRecordPosition() opencdc.Position {
+	bytes, err := json.Marshal(p)
+	if err != nil {
+		panic(fmt.Errorf("couldn't serialize position: %w", err))
+	}
+	return bytes
+}
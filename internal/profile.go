@@ -0,0 +1,72 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"math"
+	"time"
+)
+
+// Rate profiles.
+const (
+	RateProfileNone    = "none"
+	RateProfileRamp    = "ramp"
+	RateProfileStep    = "step"
+	RateProfileSine    = "sine"
+	RateProfileDiurnal = "diurnal"
+)
+
+// RateProfile describes how the rate of generated records changes over time.
+type RateProfile struct {
+	Type string
+	// Min and Max are the lowest and highest rate in records per second.
+	Min, Max float64
+	// Period is the duration of the ramp, the duration of each step, the
+	// period of the sine wave or the length of a compressed day.
+	Period time.Duration
+	// Steps are the rates of the step profile.
+	Steps []float64
+}
+
+// Enabled returns true if the rate changes over time.
+func (p RateProfile) Enabled() bool {
+	return p.Type != "" && p.Type != RateProfileNone
+}
+
+// Rate returns the rate in records per second after the given time elapsed
+// since the generator started.
+//   - ramp: increases linearly from Min to Max during Period, then stays at
+//     Max.
+//   - step: holds each of the Steps for Period, then stays at the last step.
+//   - sine: oscillates between Min and Max, starting at Min.
+//   - diurnal: follows a daily curve compressed into Period, starting at
+//     midnight, with the lowest rate at 4 AM and the highest at 4 PM.
+func (p RateProfile) Rate(elapsed time.Duration) float64 {
+	x := float64(elapsed) / float64(p.Period)
+	switch p.Type {
+	case RateProfileRamp:
+		return p.Min + (p.Max-p.Min)*min(x, 1)
+	case RateProfileStep:
+		i := min(int(x), len(p.Steps)-1)
+		return p.Steps[i]
+	case RateProfileSine:
+		return p.Min + (p.Max-p.Min)*(1-math.Cos(2*math.Pi*x))/2
+	case RateProfileDiurnal:
+		hour := math.Mod(x, 1) * 24
+		return p.Min + (p.Max-p.Min)*(1-math.Cos(2*math.Pi*(hour-4)/24))/2
+	default:
+		return p.Max
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/conduitio/conduit-commons/opencdc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protobufMaxDepth limits how deep nested messages are generated, so that
+// recursive message types terminate.
+const protobufMaxDepth = 5
+
+// NewProtobufRecordGenerator creates a RecordGenerator that generates random
+// instances of a protobuf message. The message type is loaded from the file
+// at the given path, which is either a .proto source file or a compiled
+// FileDescriptorSet. If message is empty, the file needs to contain exactly
+// one message type. If binary is true, the messages are emitted as binary
+// encoded raw data, otherwise as structured data. Random values are drawn from
+// rnd.
+func NewProtobufRecordGenerator(
+	ctx context.Context,
+	rnd *rand.Rand,
+	collection string,
+	operations []opencdc.Operation,
+	path string,
+	message string,
+	binary bool,
+) (RecordGenerator, error) {
+	var files []protoreflect.FileDescriptor
+	var err error
+	if filepath.Ext(path) == ".proto" {
+		files, err = compileProtoFile(ctx, path)
+	} else {
+		files, err = loadFileDescriptorSet(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := findProtoMessage(files, message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &baseRecordGenerator{
+		rnd:        rnd,
+		collection: collection,
+		operations: operations,
+		generateData: func(now time.Time) opencdc.Data {
+			msg := randomProtoMessage(rnd, md, 0, now)
+			if binary {
+				bytes, err := proto.Marshal(msg)
+				if err != nil {
+					panic(fmt.Errorf("couldn't serialize protobuf message: %w", err))
+				}
+				return opencdc.RawData(bytes)
+			}
+			return opencdc.StructuredData(protoMessageToMap(msg))
+		},
+	}, nil
+}
+
+func compileProtoFile(ctx context.Context, path string) ([]protoreflect.FileDescriptor, error) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: []string{filepath.Dir(path)},
+		}),
+	}
+	compiled, err := compiler.Compile(ctx, filepath.Base(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile proto file: %w", err)
+	}
+	files := make([]protoreflect.FileDescriptor, len(compiled))
+	for i, f := range compiled {
+		files[i] = f
+	}
+	return files, nil
+}
+
+func loadFileDescriptorSet(path string) ([]protoreflect.FileDescriptor, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	var fds descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(bytes, &fds); err != nil {
+		return nil, fmt.Errorf("failed to parse file descriptor set: %w", err)
+	}
+	registry, err := protodesc.NewFiles(&fds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build file descriptors: %w", err)
+	}
+
+	var files []protoreflect.FileDescriptor
+	registry.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		files = append(files, fd)
+		return true
+	})
+	return files, nil
+}
+
+// findProtoMessage returns the message with the given full name. If name is
+// empty and the files contain a single top level message, that message is
+// returned.
+func findProtoMessage(files []protoreflect.FileDescriptor, name string) (protoreflect.MessageDescriptor, error) {
+	var candidates []protoreflect.MessageDescriptor
+	for _, fd := range files {
+		if name != "" {
+			if md := findNestedProtoMessage(fd.Messages(), protoreflect.FullName(name)); md != nil {
+				return md, nil
+			}
+			continue
+		}
+		if strings.HasPrefix(fd.Path(), "google/protobuf/") {
+			continue // skip well-known types
+		}
+		for i := range fd.Messages().Len() {
+			candidates = append(candidates, fd.Messages().Get(i))
+		}
+	}
+
+	switch {
+	case name != "":
+		return nil, fmt.Errorf("message %q not found", name)
+	case len(candidates) == 0:
+		return nil, errors.New("no message types found")
+	case len(candidates) > 1:
+		return nil, fmt.Errorf("found %d message types, please specify which one to generate", len(candidates))
+	}
+	return candidates[0], nil
+}
+
+func findNestedProtoMessage(messages protoreflect.MessageDescriptors, name protoreflect.FullName) protoreflect.MessageDescriptor {
+	for i := range messages.Len() {
+		md := messages.Get(i)
+		if md.FullName() == name {
+			return md
+		}
+		if strings.HasPrefix(string(name), string(md.FullName())+".") {
+			return findNestedProtoMessage(md.Messages(), name)
+		}
+	}
+	return nil
+}
+
+// randomProtoMessage generates a message with random values in all fields.
+// Only one field of each oneof is populated. Timestamps are set to now.
+func randomProtoMessage(rnd *rand.Rand, md protoreflect.MessageDescriptor, depth int, now time.Time) *dynamicpb.Message {
+	msg := dynamicpb.NewMessage(md)
+	if md.FullName() == "google.protobuf.Timestamp" {
+		msg.Set(md.Fields().ByName("seconds"), protoreflect.ValueOfInt64(now.Unix()))
+		msg.Set(md.Fields().ByName("nanos"), protoreflect.ValueOfInt32(int32(now.Nanosecond()))) //nolint:gosec // nanoseconds fit into int32
+		return msg
+	}
+
+	fields := md.Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if oneof := fd.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			continue // populated below
+		}
+		setRandomProtoField(rnd, msg, fd, depth, now)
+	}
+	oneofs := md.Oneofs()
+	for i := range oneofs.Len() {
+		oneof := oneofs.Get(i)
+		if oneof.IsSynthetic() {
+			continue
+		}
+		setRandomProtoField(rnd, msg, oneof.Fields().Get(rnd.Intn(oneof.Fields().Len())), depth, now)
+	}
+	return msg
+}
+
+func setRandomProtoField(rnd *rand.Rand, msg *dynamicpb.Message, fd protoreflect.FieldDescriptor, depth int, now time.Time) {
+	n := 0
+	if depth < protobufMaxDepth {
+		n = rnd.Intn(4)
+	}
+	switch {
+	case fd.IsList():
+		list := msg.Mutable(fd).List()
+		for range n {
+			list.Append(randomProtoValue(rnd, fd, depth, now))
+		}
+	case fd.IsMap():
+		m := msg.Mutable(fd).Map()
+		for range n {
+			m.Set(randomProtoValue(rnd, fd.MapKey(), depth, now).MapKey(), randomProtoValue(rnd, fd.MapValue(), depth, now))
+		}
+	case fd.Message() != nil && depth >= protobufMaxDepth:
+		// leave message unset to stop recursion
+	default:
+		msg.Set(fd, randomProtoValue(rnd, fd, depth, now))
+	}
+}
+
+func randomProtoValue(rnd *rand.Rand, fd protoreflect.FieldDescriptor, depth int, now time.Time) protoreflect.Value {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(rnd.Int()%2 == 0)
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		return protoreflect.ValueOfEnum(values.Get(rnd.Intn(values.Len())).Number())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(rnd.Int31())
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(rnd.Int63())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(rnd.Uint32())
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// stay within the int64 range, so values can be represented in schemas
+		return protoreflect.ValueOfUint64(uint64(rnd.Int63()))
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(rnd.Float32())
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(rnd.Float64())
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(randomWord(rnd))
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(randomWord(rnd)))
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protoreflect.ValueOfMessage(randomProtoMessage(rnd, fd.Message(), depth+1, now))
+	default:
+		panic(fmt.Errorf("field %q contains unsupported kind: %v", fd.FullName(), fd.Kind()))
+	}
+}
+
+// protoMessageToMap converts a message into a map keyed by field names. All
+// fields are included, unset message fields are nil and only the populated
+// field of a oneof is included.
+func protoMessageToMap(msg protoreflect.Message) map[string]any {
+	fields := msg.Descriptor().Fields()
+	data := make(map[string]any, fields.Len())
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if oneof := fd.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() && !msg.Has(fd) {
+			continue
+		}
+		if fd.Message() != nil && !fd.IsList() && !fd.IsMap() && !msg.Has(fd) {
+			data[string(fd.Name())] = nil
+			continue
+		}
+		data[string(fd.Name())] = protoFieldToAny(fd, msg.Get(fd))
+	}
+	return data
+}
+
+func protoFieldToAny(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		items := make([]any, list.Len())
+		for i := range items {
+			items[i] = protoValueToAny(fd, list.Get(i))
+		}
+		return items
+	case fd.IsMap():
+		m := make(map[string]any, v.Map().Len())
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			m[k.String()] = protoValueToAny(fd.MapValue(), v)
+			return true
+		})
+		return m
+	default:
+		return protoValueToAny(fd, v)
+	}
+}
+
+func protoValueToAny(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int32(v.Enum())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// unsigned integers are not supported by all schema types, generated
+		// values are within the int64 range
+		return int64(v.Uint()) //nolint:gosec // see above
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		msg := v.Message()
+		if msg.Descriptor().FullName() == "google.protobuf.Timestamp" {
+			fields := msg.Descriptor().Fields()
+			return time.Unix(
+				msg.Get(fields.ByName("seconds")).Int(),
+				msg.Get(fields.ByName("nanos")).Int(),
+			).UTC()
+		}
+		return protoMessageToMap(msg)
+	default:
+		return v.Interface()
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule describes when records are generated.
+type Schedule struct {
+	// StartAt and StopAt limit the generation to a time range, zero values
+	// mean no limit.
+	StartAt, StopAt time.Time
+	// Windows are daily time windows in which records are generated. If
+	// empty, records are generated all day.
+	Windows []Window
+}
+
+// Window is a daily time window in UTC. If End is before Start the window
+// spans midnight.
+type Window struct {
+	// Start and End are the times of day, as durations since midnight.
+	Start, End time.Duration
+}
+
+// ParseWindow parses a window in the form "HH:MM-HH:MM".
+func ParseWindow(s string) (Window, error) {
+	start, end, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid window %q, expected HH:MM-HH:MM", s)
+	}
+	var w Window
+	var err error
+	if w.Start, err = parseTimeOfDay(start); err != nil {
+		return Window{}, err
+	}
+	if w.End, err = parseTimeOfDay(end); err != nil {
+		return Window{}, err
+	}
+	if w.Start == w.End {
+		return Window{}, fmt.Errorf("window %q is empty", s)
+	}
+	return w, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Ended returns true if no more records are generated after the given time.
+func (s Schedule) Ended(now time.Time) bool {
+	return !s.StopAt.IsZero() && !now.Before(s.StopAt)
+}
+
+// NextActive returns the earliest time at or after now at which records are
+// generated.
+func (s Schedule) NextActive(now time.Time) time.Time {
+	if now.Before(s.StartAt) {
+		now = s.StartAt
+	}
+	if len(s.Windows) == 0 {
+		return now
+	}
+
+	now = now.UTC()
+	midnight := now.Truncate(24 * time.Hour)
+	sinceMidnight := now.Sub(midnight)
+	var next time.Time
+	for _, w := range s.Windows {
+		if w.contains(sinceMidnight) {
+			return now
+		}
+		start := midnight.Add(w.Start)
+		if !start.After(now) {
+			start = start.Add(24 * time.Hour)
+		}
+		if next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+	return next
+}
+
+func (w Window) contains(t time.Duration) bool {
+	if w.Start < w.End {
+		return t >= w.Start && t < w.End
+	}
+	return t >= w.Start || t < w.End // spans midnight
+}
@@ -0,0 +1,205 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/goccy/go-json"
+)
+
+const (
+	// FillerField is the name of the field used to pad payloads to their
+	// target size.
+	FillerField = "filler"
+	// MetadataPayloadSize is the metadata key containing the size of the
+	// generated payload in bytes.
+	MetadataPayloadSize = "generator.payloadSize"
+)
+
+// Payload size distributions.
+const (
+	SizeFixed     = "fixed"
+	SizeUniform   = "uniform"
+	SizeLogNormal = "lognormal"
+)
+
+// PayloadSize describes the target size of generated payloads in bytes. The
+// zero value means the size is not controlled.
+type PayloadSize struct {
+	Distribution string
+	// Min is the fixed size or the lower bound of the uniform distribution.
+	Min int
+	// Max is the upper bound of the uniform distribution.
+	Max int
+	// Median and Sigma describe the log-normal distribution.
+	Median float64
+	Sigma  float64
+}
+
+// ParsePayloadSize parses a payload size, which is either a fixed size (e.g.
+// "512" or "4KB"), a uniform distribution (e.g. "uniform(1KB,64KB)") or a
+// log-normal distribution with a median and sigma (e.g. "lognormal(4KB,0.5)").
+// Sizes are in bytes and can have the suffix B, KB or MB (powers of 1024).
+func ParsePayloadSize(s string) (PayloadSize, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return PayloadSize{}, nil
+	}
+
+	name, params, ok := strings.Cut(s, "(")
+	if !ok {
+		size, err := ParseByteSize(s)
+		if err != nil {
+			return PayloadSize{}, err
+		}
+		return PayloadSize{Distribution: SizeFixed, Min: size}, nil
+	}
+	if !strings.HasSuffix(params, ")") {
+		return PayloadSize{}, fmt.Errorf("missing closing parenthesis in %q", s)
+	}
+	args := strings.Split(strings.TrimSuffix(params, ")"), ",")
+	if len(args) != 2 {
+		return PayloadSize{}, fmt.Errorf("expected 2 parameters in %q", s)
+	}
+
+	switch name = strings.ToLower(strings.TrimSpace(name)); name {
+	case SizeUniform:
+		lo, err1 := ParseByteSize(args[0])
+		hi, err2 := ParseByteSize(args[1])
+		if err := errors.Join(err1, err2); err != nil {
+			return PayloadSize{}, err
+		}
+		if lo > hi {
+			return PayloadSize{}, fmt.Errorf("min size %d is greater than max size %d", lo, hi)
+		}
+		return PayloadSize{Distribution: SizeUniform, Min: lo, Max: hi}, nil
+	case SizeLogNormal:
+		median, err := ParseByteSize(args[0])
+		if err != nil {
+			return PayloadSize{}, err
+		}
+		sigma, err := strconv.ParseFloat(strings.TrimSpace(args[1]), 64)
+		if err != nil || sigma < 0 {
+			return PayloadSize{}, fmt.Errorf("invalid sigma %q, expected a non-negative number", args[1])
+		}
+		if median == 0 {
+			return PayloadSize{}, errors.New("median size needs to be greater than 0")
+		}
+		return PayloadSize{Distribution: SizeLogNormal, Median: float64(median), Sigma: sigma}, nil
+	default:
+		return PayloadSize{}, fmt.Errorf("unknown size distribution %q", name)
+	}
+}
+
+// ParseByteSize parses a size in bytes with an optional suffix B, KB or MB
+// (powers of 1024).
+func ParseByteSize(s string) (int, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	unit := 1
+	for _, u := range []struct {
+		suffix string
+		size   int
+	}{{"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > math.MaxInt32/unit {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * unit, nil
+}
+
+// Enabled returns true if the payload size is controlled.
+func (p PayloadSize) Enabled() bool {
+	return p.Distribution != ""
+}
+
+// Random returns a target size drawn from the distribution.
+func (p PayloadSize) Random(rnd *rand.Rand) int {
+	switch p.Distribution {
+	case SizeUniform:
+		return p.Min + rnd.Intn(p.Max-p.Min+1)
+	case SizeLogNormal:
+		size := math.Exp(math.Log(p.Median) + p.Sigma*rnd.NormFloat64())
+		return int(min(math.Round(size), math.MaxInt32))
+	default:
+		return p.Min
+	}
+}
+
+// padStructuredData adds a filler field to the data, so that its JSON
+// representation has the target size. Data that is already bigger than the
+// target size is not changed.
+func padStructuredData(rnd *rand.Rand, data opencdc.StructuredData, target int) {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		panic(fmt.Errorf("couldn't serialize data: %w", err))
+	}
+	// the filler adds `"filler":""` and a comma if there are other fields
+	overhead := len(FillerField) + 6
+	if len(data) == 0 {
+		overhead--
+	}
+	if n := target - len(bytes) - overhead; n > 0 {
+		data[FillerField] = randomFiller(rnd, n)
+	}
+}
+
+// padEncodedData sets the filler field in the data, so that the encoded data
+// has the target size. The size of the encoded filler can depend on its
+// length (e.g. length prefixes), so the length is corrected once.
+func padEncodedData(
+	rnd *rand.Rand,
+	data opencdc.StructuredData,
+	target int,
+	encode func(opencdc.StructuredData) ([]byte, error),
+) ([]byte, error) {
+	data[FillerField] = ""
+	bytes, err := encode(data)
+	if err != nil {
+		return nil, err
+	}
+	n := target - len(bytes)
+	if n <= 0 {
+		return bytes, nil
+	}
+
+	data[FillerField] = randomFiller(rnd, n)
+	bytes, err = encode(data)
+	if err != nil || len(bytes) <= target {
+		return bytes, err
+	}
+	data[FillerField] = randomFiller(rnd, max(0, n-(len(bytes)-target)))
+	return encode(data)
+}
+
+// randomFiller returns a random string of lowercase letters with length n.
+func randomFiller(rnd *rand.Rand, n int) string {
+	b := randomBytes(rnd, n)
+	for i := range b {
+		b[i] = 'a' + b[i]%26
+	}
+	return string(b)
+}
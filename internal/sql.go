@@ -0,0 +1,928 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+// sqlNullRatio is the ratio of NULL values generated for nullable columns.
+const sqlNullRatio = 0.1
+
+// NewSQLRecordGenerator creates a RecordGenerator that generates records based
+// on the CREATE TABLE statements in the SQL DDL file at the given path. Each
+// table is generated in its own collection, named after the table. Columns
+// declared as PRIMARY KEY are used as the record key. Integer primary keys are
+// sequential, updates and deletes only change rows that were created before.
+// Columns that reference another table in the same file only contain primary
+// keys of existing rows of that table. Random values are drawn from rnd.
+func NewSQLRecordGenerator(
+	rnd *rand.Rand,
+	operations []opencdc.Operation,
+	path string,
+) (RecordGenerator, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	tables, err := parseSQLTables(string(bytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SQL DDL: %w", err)
+	}
+	if len(tables) == 0 {
+		return nil, errors.New("no CREATE TABLE statements found")
+	}
+
+	byName := make(map[string]*sqlTable, len(tables))
+	for _, t := range tables {
+		byName[t.name] = t
+	}
+
+	if err := checkSQLReferences(tables, byName); err != nil {
+		return nil, err
+	}
+
+	generators := make([]RecordGenerator, len(tables))
+	for i, t := range tables {
+		generators[i] = &baseRecordGenerator{
+			rnd:        rnd,
+			collection: t.name,
+			operations: operations,
+			keyFields:  t.primaryKey,
+			generateData: func(now time.Time) opencdc.Data {
+				return t.randomRow(rnd, byName, now)
+			},
+		}
+	}
+	return &sqlRecordGenerator{
+		RecordGenerator: Combine(rnd, generators...),
+		rnd:             rnd,
+		tables:          tables,
+		byName:          byName,
+		generators:      generators,
+	}, nil
+}
+
+// sqlRecordGenerator randomly selects the table of the next record. A table
+// referencing another table that doesn't contain any rows yet is only
+// generated after a row of the referenced table, so foreign keys don't point
+// to missing rows.
+type sqlRecordGenerator struct {
+	RecordGenerator
+	rnd        *rand.Rand
+	tables     []*sqlTable
+	byName     map[string]*sqlTable
+	generators []RecordGenerator
+}
+
+func (g *sqlRecordGenerator) Next() opencdc.Record {
+	i := g.rnd.Intn(len(g.tables))
+	// the references are acyclic, so following them ends after all tables
+	for range g.tables {
+		parent := g.emptyParent(g.tables[i])
+		if parent < 0 {
+			break
+		}
+		i = parent
+	}
+	rec := g.generators[i].Next()
+	g.tables[i].setKey(g.rnd, &rec)
+	return rec
+}
+
+// setKey sets the sequential primary key of the row in the record. Creates
+// add a row with the next key, updates change a random existing row and
+// deletes remove the last created row, so the keys 1 to count always
+// identify existing rows. Updates and deletes in a table without rows are
+// turned into creates.
+func (t *sqlTable) setKey(rnd *rand.Rand, rec *opencdc.Record) {
+	if (rec.Operation == opencdc.OperationUpdate || rec.Operation == opencdc.OperationDelete) && t.count == 0 {
+		after := rec.Payload.After
+		if after == nil {
+			after = rec.Payload.Before
+		}
+		rec.Operation = opencdc.OperationCreate
+		rec.Payload = opencdc.Change{After: after}
+	}
+
+	var id int
+	switch rec.Operation {
+	case opencdc.OperationUpdate:
+		id = rnd.Intn(t.count) + 1
+	case opencdc.OperationDelete:
+		id = t.count
+		t.count--
+	default:
+		t.count++
+		id = t.count
+	}
+	for _, data := range []opencdc.Data{rec.Key, rec.Payload.Before, rec.Payload.After} {
+		sd, ok := data.(opencdc.StructuredData)
+		if !ok {
+			continue
+		}
+		for _, c := range t.columns {
+			if _, ok := sd[c.name]; ok && c.sequential() {
+				sd[c.name] = id
+			}
+		}
+	}
+}
+
+func (g *sqlRecordGenerator) State() map[string]GeneratorState {
+	states := g.RecordGenerator.State()
+	for _, t := range g.tables {
+		st := states[t.name]
+		st.Rows = t.count
+		states[t.name] = st
+	}
+	return states
+}
+
+func (g *sqlRecordGenerator) Restore(states map[string]GeneratorState) {
+	g.RecordGenerator.Restore(states)
+	for _, t := range g.tables {
+		if st, ok := states[t.name]; ok {
+			t.count = st.Rows
+		}
+	}
+}
+
+// emptyParent returns the index of a table referenced by t that doesn't
+// contain any rows yet, or -1 if there is none.
+func (g *sqlRecordGenerator) emptyParent(t *sqlTable) int {
+	for _, c := range t.columns {
+		if c.references == nil || c.references.table == t.name {
+			continue
+		}
+		ref, ok := g.byName[c.references.table]
+		if !ok || ref.count > 0 {
+			continue
+		}
+		return slices.Index(g.tables, ref)
+	}
+	return -1
+}
+
+// checkSQLReferences returns an error if tables reference each other in a
+// cycle, as no table in the cycle could be generated first, or if a column
+// references anything else than a sequential primary key of a table in the
+// file, as only these keys are known to identify existing rows.
+func checkSQLReferences(tables []*sqlTable, byName map[string]*sqlTable) error {
+	for _, t := range tables {
+		for _, c := range t.columns {
+			if c.references == nil {
+				continue
+			}
+			ref, ok := byName[c.references.table]
+			if !ok {
+				// tables outside the file can't be checked
+				continue
+			}
+			if refCol := ref.referencedColumn(c.references); refCol == nil || !refCol.sequential() {
+				return fmt.Errorf("table %q: column %q can only reference an integer primary key of table %q", t.name, c.name, ref.name)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(tables))
+	var visit func(t *sqlTable) error
+	visit = func(t *sqlTable) error {
+		switch state[t.name] {
+		case visiting:
+			return fmt.Errorf("table %q: cyclic foreign key references", t.name)
+		case visited:
+			return nil
+		}
+		state[t.name] = visiting
+		for _, c := range t.columns {
+			if c.references == nil || c.references.table == t.name {
+				continue
+			}
+			if ref, ok := byName[c.references.table]; ok {
+				if err := visit(ref); err != nil {
+					return err
+				}
+			}
+		}
+		state[t.name] = visited
+		return nil
+	}
+	for _, t := range tables {
+		if err := visit(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type sqlTable struct {
+	name       string
+	columns    []*sqlColumn
+	primaryKey []string
+	// count is the number of existing rows, which have the sequential
+	// integer primary keys 1 to count.
+	count int
+}
+
+type sqlColumn struct {
+	name string
+	// typ is the normalized column type (int, float, decimal, string, bool,
+	// date, timestamp, time, uuid, bytes, json).
+	typ string
+	// length is the maximum length of string columns (0 means unlimited).
+	length int
+	// scale is the number of fractional digits of decimal columns.
+	scale   int
+	notNull bool
+	// lower and upper are the value bounds derived from CHECK constraints.
+	lower, upper *float64
+	// values are the allowed values derived from CHECK ... IN (...) or enum
+	// column types.
+	values     []any
+	references *sqlReference
+	primaryKey bool
+}
+
+type sqlReference struct {
+	table  string
+	column string
+}
+
+func (t *sqlTable) column(name string) *sqlColumn {
+	for _, c := range t.columns {
+		if strings.EqualFold(c.name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// referencedColumn returns the column of t referenced by ref, or nil if
+// there is none.
+func (t *sqlTable) referencedColumn(ref *sqlReference) *sqlColumn {
+	if ref.column == "" {
+		if len(t.primaryKey) != 1 {
+			return nil
+		}
+		return t.column(t.primaryKey[0])
+	}
+	return t.column(ref.column)
+}
+
+// randomRow generates a row of the table. Sequential primary keys are set by
+// setKey, once the operation of the record is known.
+func (t *sqlTable) randomRow(rnd *rand.Rand, tables map[string]*sqlTable, now time.Time) opencdc.StructuredData {
+	row := make(opencdc.StructuredData, len(t.columns))
+	for _, c := range t.columns {
+		row[c.name] = c.randomValue(rnd, tables, now)
+	}
+	return row
+}
+
+// sequential returns true if the column is an integer primary key, which is
+// generated as a sequence and can be referenced by other tables.
+func (c *sqlColumn) sequential() bool {
+	return c.primaryKey && c.typ == "int" && c.references == nil
+}
+
+func (c *sqlColumn) randomValue(rnd *rand.Rand, tables map[string]*sqlTable, now time.Time) any {
+	if c.sequential() {
+		return 0
+	}
+	if c.references != nil {
+		if ref, ok := tables[c.references.table]; ok {
+			// only reference existing rows, a table referencing itself
+			// starts with a row without a reference
+			if ref.count == 0 {
+				return nil
+			}
+			if !c.notNull && rnd.Float64() < sqlNullRatio {
+				return nil
+			}
+			return rnd.Intn(ref.count) + 1
+		}
+	}
+	if !c.notNull && rnd.Float64() < sqlNullRatio {
+		return nil
+	}
+
+	if len(c.values) > 0 {
+		return c.values[rnd.Intn(len(c.values))]
+	}
+
+	switch c.typ {
+	case "int":
+		lo, hi := c.bounds(0, math.MaxInt32)
+		return randomInt(rnd, lo, hi)
+	case "float":
+		lo, hi := c.bounds(0, 1e6)
+		return lo + rnd.Float64()*(hi-lo)
+	case "decimal":
+		lo, hi := c.bounds(0, 1e6)
+		pow := math.Pow10(c.scale)
+		return math.Round((lo+rnd.Float64()*(hi-lo))*pow) / pow
+	case "string":
+		s := randomWord(rnd)
+		if c.length > 0 && len(s) > c.length {
+			s = s[:c.length]
+		}
+		return s
+	case "bool":
+		return rnd.Int()%2 == 0
+	case "date":
+		return now.UTC().Truncate(24 * time.Hour)
+	case "timestamp", "time":
+		return now.UTC()
+	case "uuid":
+		return randomUUID(rnd)
+	case "bytes":
+		return []byte(randomWord(rnd))
+	case "json":
+		return map[string]any{randomWord(rnd): rnd.Int()}
+	default:
+		panic(fmt.Errorf("column %q contains invalid type: %v", c.name, c.typ))
+	}
+}
+
+func (c *sqlColumn) bounds(defaultMin, defaultMax float64) (float64, float64) {
+	lo, hi := defaultMin, defaultMax
+	if c.lower != nil {
+		lo = *c.lower
+		if c.upper == nil && hi < lo {
+			hi = lo + defaultMax
+		}
+	}
+	if c.upper != nil {
+		hi = *c.upper
+		if c.lower == nil && lo > hi {
+			lo = hi - defaultMax
+		}
+	}
+	return lo, max(lo, hi)
+}
+
+// -- parser -------------------------------------------------------------------
+
+// parseSQLTables parses all CREATE TABLE statements in the DDL. Other
+// statements are ignored.
+func parseSQLTables(ddl string) ([]*sqlTable, error) {
+	tokens, err := tokenizeSQL(ddl)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*sqlTable
+	for _, stmt := range splitSQL(tokens, ";") {
+		if len(stmt) == 0 || !stmt[0].is("CREATE") {
+			continue
+		}
+		i := 1
+		for i < len(stmt) && (stmt[i].is("TEMPORARY") || stmt[i].is("TEMP") || stmt[i].is("UNLOGGED")) {
+			i++
+		}
+		if i >= len(stmt) || !stmt[i].is("TABLE") {
+			continue // not a table
+		}
+		t, err := parseSQLCreateTable(stmt[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+func parseSQLCreateTable(tokens []sqlToken) (*sqlTable, error) {
+	if len(tokens) > 2 && tokens[0].is("IF") && tokens[1].is("NOT") && tokens[2].is("EXISTS") {
+		tokens = tokens[3:]
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("missing table name")
+	}
+
+	// skip schema qualifiers, the table name is the last identifier
+	name := tokens[0].text
+	i := 1
+	for i+1 < len(tokens) && tokens[i].text == "." {
+		name = tokens[i+1].text
+		i += 2
+	}
+	t := &sqlTable{name: name}
+
+	if i >= len(tokens) || tokens[i].text != "(" {
+		return nil, fmt.Errorf("table %q: expected column definitions", name)
+	}
+	body, _ := sqlParens(tokens[i:])
+
+	var checks [][]sqlToken
+	for _, def := range splitSQL(body, ",") {
+		if len(def) == 0 {
+			continue
+		}
+		if def[0].is("CONSTRAINT") && len(def) > 2 {
+			def = def[2:]
+		}
+		switch {
+		case def[0].is("PRIMARY") && len(def) > 2:
+			cols, _ := sqlParens(def[2:])
+			for _, c := range splitSQL(cols, ",") {
+				t.primaryKey = append(t.primaryKey, c[0].text)
+			}
+		case def[0].is("FOREIGN") && len(def) > 2:
+			cols, rest := sqlParens(def[2:])
+			if len(rest) == 0 || !rest[0].is("REFERENCES") {
+				continue
+			}
+			ref, _ := parseSQLReference(rest[1:])
+			for _, c := range splitSQL(cols, ",") {
+				if col := t.column(c[0].text); col != nil {
+					col.references = ref
+				}
+			}
+		case def[0].is("CHECK"):
+			expr, _ := sqlParens(def[1:])
+			checks = append(checks, expr)
+		case def[0].is("UNIQUE"), def[0].is("KEY"), def[0].is("INDEX"),
+			def[0].is("FULLTEXT"), def[0].is("SPATIAL"), def[0].is("EXCLUDE"):
+			// not relevant for generating data
+		default:
+			col, colChecks, err := parseSQLColumn(def)
+			if err != nil {
+				return nil, fmt.Errorf("table %q: %w", name, err)
+			}
+			if col.primaryKey {
+				t.primaryKey = append(t.primaryKey, col.name)
+			}
+			t.columns = append(t.columns, col)
+			checks = append(checks, colChecks...)
+		}
+	}
+
+	for _, pk := range t.primaryKey {
+		col := t.column(pk)
+		if col == nil {
+			return nil, fmt.Errorf("table %q: primary key column %q not found", name, pk)
+		}
+		col.primaryKey = true
+		col.notNull = true
+	}
+	for _, expr := range checks {
+		applySQLCheck(t, expr)
+	}
+	for _, c := range t.columns {
+		if err := c.checkBounds(); err != nil {
+			return nil, fmt.Errorf("table %q: %w", name, err)
+		}
+	}
+	return t, nil
+}
+
+func parseSQLColumn(def []sqlToken) (*sqlColumn, [][]sqlToken, error) {
+	if len(def) < 2 {
+		return nil, nil, fmt.Errorf("column %q: missing type", def[0].text)
+	}
+	col := &sqlColumn{name: def[0].text}
+
+	// collect type name, can consist of multiple words (e.g. double precision)
+	typeWords := []string{strings.ToLower(def[1].text)}
+	i := 2
+	for i < len(def) && def[i].kind == sqlTokenWord && !isSQLConstraintKeyword(def[i]) {
+		typeWords = append(typeWords, strings.ToLower(def[i].text))
+		i++
+	}
+	var typeArgs []sqlToken
+	if i < len(def) && def[i].text == "(" {
+		typeArgs, def = sqlParens(def[i:])
+		i = 0
+		// type modifiers after the arguments (e.g. unsigned)
+		for i < len(def) && def[i].kind == sqlTokenWord && !isSQLConstraintKeyword(def[i]) {
+			i++
+		}
+	}
+	if err := col.setType(strings.Join(typeWords, " "), typeArgs); err != nil {
+		return nil, nil, err
+	}
+
+	var checks [][]sqlToken
+	rest := def[i:]
+	for len(rest) > 0 {
+		tok := rest[0]
+		rest = rest[1:]
+		switch {
+		case tok.is("NOT") && len(rest) > 0 && rest[0].is("NULL"):
+			col.notNull = true
+			rest = rest[1:]
+		case tok.is("PRIMARY"):
+			col.primaryKey = true
+			if len(rest) > 0 && rest[0].is("KEY") {
+				rest = rest[1:]
+			}
+		case tok.is("REFERENCES"):
+			col.references, rest = parseSQLReference(rest)
+		case tok.is("CHECK") && len(rest) > 0 && rest[0].text == "(":
+			var expr []sqlToken
+			expr, rest = sqlParens(rest)
+			checks = append(checks, expr)
+		case tok.is("DEFAULT") && len(rest) > 0:
+			// skip default expression
+			if len(rest) > 1 && rest[1].text == "(" {
+				_, rest = sqlParens(rest[1:])
+			} else {
+				rest = rest[1:]
+			}
+		}
+	}
+	return col, checks, nil
+}
+
+func (c *sqlColumn) setType(typ string, args []sqlToken) error {
+	var nums []int
+	for _, a := range splitSQL(args, ",") {
+		if n, err := strconv.Atoi(a[0].text); err == nil {
+			nums = append(nums, n)
+		}
+	}
+	typ = strings.TrimSuffix(typ, " unsigned")
+
+	switch typ {
+	case "int", "integer", "smallint", "bigint", "tinyint", "mediumint",
+		"serial", "smallserial", "bigserial", "int2", "int4", "int8":
+		c.typ = "int"
+		if typ == "tinyint" && len(nums) > 0 && nums[0] == 1 {
+			c.typ = "bool" // MySQL boolean
+		}
+	case "real", "float", "float4", "float8", "double", "double precision":
+		c.typ = "float"
+	case "decimal", "numeric", "dec", "money":
+		c.typ = "decimal"
+		c.scale = 2
+		if len(nums) > 1 {
+			c.scale = nums[1]
+		}
+		if len(nums) > 0 {
+			// keep values within the declared precision
+			maxVal := math.Pow10(nums[0]-c.scale) - math.Pow10(-c.scale)
+			c.upper = &maxVal
+		}
+	case "char", "character", "varchar", "character varying", "nchar",
+		"nvarchar", "text", "tinytext", "mediumtext", "longtext", "string",
+		"citext", "clob":
+		c.typ = "string"
+		if len(nums) > 0 {
+			c.length = nums[0]
+		}
+	case "bool", "boolean", "bit":
+		c.typ = "bool"
+	case "date":
+		c.typ = "date"
+	case "timestamp", "timestamptz", "datetime", "timestamp with time zone",
+		"timestamp without time zone":
+		c.typ = "timestamp"
+	case "time", "timetz", "time with time zone", "time without time zone":
+		c.typ = "time"
+	case "uuid", "uniqueidentifier":
+		c.typ = "uuid"
+	case "bytea", "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary":
+		c.typ = "bytes"
+	case "json", "jsonb":
+		c.typ = "json"
+	case "enum", "set":
+		c.typ = "string"
+		for _, a := range splitSQL(args, ",") {
+			c.values = append(c.values, a[0].text)
+		}
+	default:
+		return fmt.Errorf("column %q: unsupported type %q", c.name, typ)
+	}
+	return nil
+}
+
+// parseSQLReference parses the referenced table and column following the
+// REFERENCES keyword and returns the remaining tokens.
+func parseSQLReference(tokens []sqlToken) (*sqlReference, []sqlToken) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	ref := &sqlReference{table: tokens[0].text}
+	i := 1
+	for i+1 < len(tokens) && tokens[i].text == "." {
+		ref.table = tokens[i+1].text
+		i += 2
+	}
+	rest := tokens[i:]
+	if len(rest) > 0 && rest[0].text == "(" {
+		var cols []sqlToken
+		cols, rest = sqlParens(rest)
+		if len(cols) > 0 {
+			ref.column = cols[0].text
+		}
+	}
+	return ref, rest
+}
+
+// applySQLCheck applies a CHECK constraint to the columns of the table. Only
+// simple comparisons (col > n, col BETWEEN a AND b, col IN (...)) combined
+// with AND are supported, other expressions are ignored.
+func applySQLCheck(t *sqlTable, expr []sqlToken) {
+	// unwrap redundant parentheses
+	for len(expr) > 0 && expr[0].text == "(" {
+		inner, rest := sqlParens(expr)
+		if len(rest) > 0 {
+			break
+		}
+		expr = inner
+	}
+
+	for _, cond := range splitSQLAnd(expr) {
+		if len(cond) < 3 {
+			continue
+		}
+		col := t.column(cond[0].text)
+		if col == nil {
+			continue
+		}
+		switch op := cond[1].text; {
+		case op == ">" || op == ">=" || op == "<" || op == "<=":
+			n, err := strconv.ParseFloat(cond[2].text, 64)
+			if err != nil {
+				continue
+			}
+			col.applyBound(op, n)
+		case cond[1].is("BETWEEN") && len(cond) >= 5 && cond[3].is("AND"):
+			lo, err1 := strconv.ParseFloat(cond[2].text, 64)
+			hi, err2 := strconv.ParseFloat(cond[4].text, 64)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			col.applyBound(">=", lo)
+			col.applyBound("<=", hi)
+		case cond[1].is("IN") && cond[2].text == "(":
+			list, _ := sqlParens(cond[2:])
+			col.values = nil
+			for _, v := range splitSQL(list, ",") {
+				col.values = append(col.values, col.parseValue(v[0]))
+			}
+		}
+	}
+}
+
+func (c *sqlColumn) applyBound(op string, n float64) {
+	switch op {
+	case ">":
+		if c.typ == "int" {
+			n = math.Floor(n) + 1
+		} else {
+			n = math.Nextafter(n, math.Inf(1))
+		}
+		c.lower = &n
+	case ">=":
+		c.lower = &n
+	case "<":
+		if c.typ == "int" {
+			n = math.Ceil(n) - 1
+		} else {
+			n = math.Nextafter(n, math.Inf(-1))
+		}
+		c.upper = &n
+	case "<=":
+		c.upper = &n
+	}
+}
+
+// checkBounds returns an error if the bounds of a numeric column don't allow
+// any value, e.g. CHECK (x > 1.2 AND x < 1.8) for an integer column.
+func (c *sqlColumn) checkBounds() error {
+	if len(c.values) > 0 || c.lower == nil || c.upper == nil {
+		return nil
+	}
+	lo, hi := *c.lower, *c.upper
+	if c.typ == "int" {
+		lo, hi = math.Ceil(lo), math.Floor(hi)
+	}
+	if lo > hi {
+		return fmt.Errorf("column %q: CHECK constraints don't allow any %s value", c.name, c.typ)
+	}
+	return nil
+}
+
+func (c *sqlColumn) parseValue(tok sqlToken) any {
+	if tok.kind == sqlTokenString {
+		return tok.text
+	}
+	switch c.typ {
+	case "int":
+		if n, err := strconv.Atoi(tok.text); err == nil {
+			return n
+		}
+	case "float", "decimal":
+		if n, err := strconv.ParseFloat(tok.text, 64); err == nil {
+			return n
+		}
+	}
+	return tok.text
+}
+
+// -- tokenizer ----------------------------------------------------------------
+
+const (
+	sqlTokenWord = iota
+	sqlTokenString
+	sqlTokenSymbol
+)
+
+type sqlToken struct {
+	kind int
+	text string
+}
+
+// is checks if the token is the given keyword (case-insensitive).
+func (t sqlToken) is(keyword string) bool {
+	return t.kind == sqlTokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func isSQLConstraintKeyword(t sqlToken) bool {
+	for _, k := range []string{
+		"NOT", "NULL", "PRIMARY", "REFERENCES", "CHECK", "DEFAULT", "UNIQUE",
+		"CONSTRAINT", "AUTO_INCREMENT", "AUTOINCREMENT", "GENERATED",
+		"COLLATE", "COMMENT", "IDENTITY", "CHARACTER", "ON",
+	} {
+		if t.is(k) {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenizeSQL(s string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			j := i + 2
+			for j+1 < len(runes) && (runes[j] != '*' || runes[j+1] != '/') {
+				j++
+			}
+			if j+1 >= len(runes) {
+				return nil, errors.New("unterminated comment")
+			}
+			i = j + 2
+		case r == '\'' || r == '"' || r == '`' || r == '[':
+			closing := r
+			if r == '[' {
+				closing = ']'
+			}
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == closing {
+					if j+1 < len(runes) && runes[j+1] == closing {
+						sb.WriteRune(closing) // escaped quote
+						j++
+						continue
+					}
+					break
+				}
+				sb.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated quote %q", r)
+			}
+			kind := sqlTokenWord // quoted identifier
+			if r == '\'' {
+				kind = sqlTokenString
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: sb.String()})
+			i = j + 1
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' ||
+			(r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '$' ||
+				(runes[j] == '.' && unicode.IsDigit(runes[i]))) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenWord, text: string(runes[i:j])})
+			i = j
+		case (r == '>' || r == '<' || r == '!') && i+1 < len(runes) && runes[i+1] == '=':
+			tokens = append(tokens, sqlToken{kind: sqlTokenSymbol, text: string(runes[i : i+2])})
+			i += 2
+		default:
+			tokens = append(tokens, sqlToken{kind: sqlTokenSymbol, text: string(r)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// sqlParens expects tokens to start with an opening parenthesis and returns
+// the tokens inside the matching parentheses and the tokens after them.
+func sqlParens(tokens []sqlToken) (inner, rest []sqlToken) {
+	if len(tokens) == 0 || tokens[0].text != "(" {
+		return nil, tokens
+	}
+	depth := 0
+	for i, t := range tokens {
+		if t.kind != sqlTokenSymbol {
+			continue
+		}
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return tokens[1:i], tokens[i+1:]
+			}
+		}
+	}
+	return tokens[1:], nil
+}
+
+// splitSQL splits tokens by the separator symbol on the top level (i.e. not
+// within parentheses).
+func splitSQL(tokens []sqlToken, sep string) [][]sqlToken {
+	var parts [][]sqlToken
+	depth, start := 0, 0
+	for i, t := range tokens {
+		if t.kind != sqlTokenSymbol {
+			continue
+		}
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(tokens) {
+		parts = append(parts, tokens[start:])
+	}
+	return parts
+}
+
+// splitSQLAnd splits a boolean expression by top level AND keywords, keeping
+// BETWEEN ... AND ... intact.
+func splitSQLAnd(tokens []sqlToken) [][]sqlToken {
+	var parts [][]sqlToken
+	depth, start := 0, 0
+	between := false
+	for i, t := range tokens {
+		switch {
+		case t.text == "(":
+			depth++
+		case t.text == ")":
+			depth--
+		case depth == 0 && t.is("BETWEEN"):
+			between = true
+		case depth == 0 && t.is("AND"):
+			if between {
+				between = false
+				continue
+			}
+			parts = append(parts, tokens[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, tokens[start:])
+}
@@ -0,0 +1,185 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Trace formats.
+const (
+	TraceTimestamps = "timestamps"
+	TraceCounts     = "counts"
+)
+
+// Trace replays the timing of records recorded in a trace file.
+type Trace struct {
+	// offsets contains the time of each record relative to the first one, if
+	// the trace contains timestamps.
+	offsets []time.Duration
+	// counts contains the number of records in consecutive seconds, if the
+	// trace contains counts.
+	counts []int
+	// length is the duration of the trace, used when looping.
+	length time.Duration
+	speed  float64
+	loop   bool
+
+	// i is the index of the next timestamp or second, j the index of the
+	// next record in the second.
+	i, j int
+	// iteration is the number of times the trace was replayed.
+	iteration int
+}
+
+// LoadTrace loads the trace file at the given path. A trace in the format
+// TraceTimestamps contains one timestamp per line, either in RFC 3339 format
+// or as a Unix timestamp in seconds (values above 1e11 are treated as
+// milliseconds). A trace in the format TraceCounts contains the number of
+// records in consecutive seconds, one per line. Lines can contain multiple
+// comma separated columns, in which case the last column is used (e.g.
+// "timestamp,count"). Empty lines, comments starting with "#" and a header
+// line are skipped. The trace is sped up by speed and restarts after it ended
+// if loop is true.
+func LoadTrace(path, format string, speed float64, loop bool) (*Trace, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	defer f.Close()
+
+	t := &Trace{speed: speed, loop: loop}
+	var timestamps []time.Time
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if i := strings.LastIndex(text, ","); i >= 0 {
+			text = strings.TrimSpace(text[i+1:])
+		}
+
+		switch format {
+		case TraceCounts:
+			n, err := strconv.Atoi(text)
+			if err == nil && n < 0 {
+				err = errors.New("count is negative")
+			}
+			if err != nil {
+				if len(t.counts) == 0 {
+					continue // header
+				}
+				return nil, fmt.Errorf("invalid count on line %d: %w", line, err)
+			}
+			t.counts = append(t.counts, n)
+		default:
+			ts, err := parseTraceTimestamp(text)
+			if err != nil {
+				if len(timestamps) == 0 {
+					continue // header
+				}
+				return nil, fmt.Errorf("invalid timestamp on line %d: %w", line, err)
+			}
+			timestamps = append(timestamps, ts)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trace file: %w", err)
+	}
+
+	if format == TraceCounts {
+		if len(t.counts) == 0 {
+			return nil, errors.New("trace file contains no counts")
+		}
+		t.length = time.Duration(len(t.counts)) * time.Second
+		return t, nil
+	}
+
+	if len(timestamps) == 0 {
+		return nil, errors.New("trace file contains no timestamps")
+	}
+	slices.SortFunc(timestamps, func(a, b time.Time) int { return a.Compare(b) })
+	t.offsets = make([]time.Duration, len(timestamps))
+	for i, ts := range timestamps {
+		t.offsets[i] = ts.Sub(timestamps[0])
+	}
+	// the loop restarts after the average gap between records
+	t.length = time.Second
+	if n := len(t.offsets); n > 1 {
+		t.length = t.offsets[n-1] + t.offsets[n-1]/time.Duration(n-1)
+	}
+	return t, nil
+}
+
+func parseTraceTimestamp(s string) (time.Time, error) {
+	if ts, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return ts, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 or Unix timestamp, got %q", s)
+	}
+	if f > 1e11 {
+		f /= 1000 // milliseconds
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), nil
+}
+
+// Next returns the time at which the next record is due, relative to the
+// start of the replay. It returns false if the trace ended.
+func (t *Trace) Next() (time.Duration, bool) {
+	var offset time.Duration
+	switch {
+	case t.counts != nil:
+		// skip seconds without records
+		for t.i < len(t.counts) && t.j >= t.counts[t.i] {
+			t.i, t.j = t.i+1, 0
+		}
+		if t.i == len(t.counts) {
+			if !t.loop || slices.Max(t.counts) == 0 {
+				return 0, false
+			}
+			t.i, t.j = 0, 0
+			t.iteration++
+			return t.Next()
+		}
+		// spread the records evenly across the second
+		offset = time.Duration(t.i)*time.Second + time.Duration(t.j)*time.Second/time.Duration(t.counts[t.i])
+		t.j++
+	default:
+		if t.i == len(t.offsets) {
+			if !t.loop {
+				return 0, false
+			}
+			t.i = 0
+			t.iteration++
+		}
+		offset = t.offsets[t.i]
+		t.i++
+	}
+
+	offset += time.Duration(t.iteration) * t.length
+	return time.Duration(float64(offset) / t.speed), true
+}
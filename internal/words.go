@@ -32,6 +32,6 @@ func init() {
 	wordsRaw = "" // clear the raw data
 }
 
-func randomWord() string {
-	return words[rand.Intn(len(words))]
+func randomWord(rnd *rand.Rand) string {
+	return words[rnd.Intn(len(words))]
 }
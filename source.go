@@ -16,7 +16,11 @@ package generator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"math/rand"
+	"slices"
 	"time"
 
 	"github.com/conduitio/conduit-commons/opencdc"
@@ -25,18 +29,105 @@ import (
 	"golang.org/x/time/rate"
 )
 
+// ErrEndOfStream is returned by Read after the last record was generated, if
+// the end of stream behavior is EndOfStreamError. It's an ordinary read error
+// for Conduit, which stops the pipeline in a failed state.
+var ErrEndOfStream = errors.New("end of stream: no more records to generate")
+
+// ErrInjectedFault is wrapped by the errors returned when faults are injected
+// as configured in `faults.*`. The errors are of type *internal.FaultError.
+// Transient faults also wrap sdk.ErrBackoffRetry, so the SDK retries reading
+// after a backoff, permanent faults stop the pipeline.
+var ErrInjectedFault = internal.ErrInjectedFault
+
+// ErrAckViolation is returned by Ack and Teardown if acknowledgments are
+// unknown, duplicated, out of order or missing and `acks.failOnViolation` is
+// set.
+var ErrAckViolation = errors.New("ack violation")
+
 // Source connector
 type Source struct {
 	sdk.UnimplementedSource
 
 	config      Config
 	recordCount int
+	burst       BurstConfig
 	burstUntil  time.Time
 
+	// generators contains the record generator of each collection,
+	// recordGenerator combines the generators of the active collections.
+	generators      map[string]internal.RecordGenerator
 	recordGenerator internal.RecordGenerator
 	rateLimiter     *rate.Limiter
+	rateProfile     internal.RateProfile
+	openedAt        time.Time
+	// arrival determines the time between records, nextArrival is the time
+	// the next record is due if the arrival model is not constant.
+	arrival     internal.ArrivalModel
+	nextArrival time.Time
+	// trace drives the timing of records if configured. traceDue is the time
+	// a record of the trace that wasn't emitted is due, it's zero if all
+	// records taken from the trace were emitted.
+	trace    *internal.Trace
+	traceDue time.Time
+	// schedule determines when records are generated.
+	schedule internal.Schedule
+	// faults decides when reading a record fails.
+	faults *internal.FaultInjector
+	// clock is the simulated clock, nil if the wall clock is used.
+	clock *internal.SimulatedClock
+	// wallClock paces the generated records, it's only replaced in tests.
+	wallClock internal.Clock
+	// rand is the source of all random values and decisions, so that a
+	// seed reproduces the generated records.
+	rand *rand.Rand
+	// seq is the sequence number of the last emitted position, acks verifies
+	// the acknowledgments of emitted positions.
+	seq  int64
+	acks *internal.AckTracker
+	// lastAckLog is the time the ack statistics were last logged.
+	lastAckLog time.Time
+	// inFlight contains an element for each record that was read but not
+	// acknowledged yet, nil if the number of records in flight is not
+	// limited.
+	inFlight chan struct{}
+
+	// recent contains the last generated records, which are replayed after
+	// a restart, and recentSizes their sizes. replay contains the records
+	// left to replay, duplicates the duplicates to emit and redelivered the
+	// number of duplicates emitted since the last generated record.
+	recent      []opencdc.Record
+	recentSizes []int
+	replay      []opencdc.Record
+	duplicates  []scheduledRecord
+	redelivered int
+	// unsent contains records that were prepared for a batch but not
+	// emitted, because the read was canceled or a fault is injected first.
+	// They are emitted before any other record.
+	unsent []preparedRecord
+
+	// phases are executed sequentially, phase is the index of the current
+	// phase and phaseCount the number of records generated in it.
+	phases         []PhaseConfig
+	phase          int
+	phaseCount     int
+	phaseStartedAt time.Time
 }
 
+const (
+	// minProfileRate is the lowest rate applied by a rate profile, while the
+	// profile is below it the source waits for the rate to increase.
+	minProfileRate = 0.1
+	// rateProfileTick is the interval in which the rate of a rate profile is
+	// checked while waiting for it to increase.
+	rateProfileTick = 100 * time.Millisecond
+	// maxArrivalBacklog is the number of records by which the source can
+	// fall behind the schedule of the arrival model. Records that are behind
+	// are emitted without waiting to catch up, beyond the backlog the
+	// schedule moves on, so a stalled pipeline doesn't cause a large burst.
+	maxArrivalBacklog = 100
+)
+
 func NewSource() sdk.Source {
 	return sdk.SourceWithMiddleware(&Source{})
 }
@@ -45,84 +136,696 @@ func (s *Source) Config() sdk.SourceConfig {
 	return &s.config
 }
 
-func (s *Source) Open(_ context.Context, _ opencdc.Position) error {
-	var generators []internal.RecordGenerator
-	for collection, cfg := range s.config.GetCollectionConfigs() {
+func (s *Source) Open(ctx context.Context, pos opencdc.Position) error {
+	if s.config.Faults.Open {
+		return &internal.FaultError{Kind: internal.FaultPermanent, Reason: "failed to open"}
+	}
+
+	if s.wallClock == nil {
+		s.wallClock = internal.WallClock{}
+	}
+	s.clock = s.config.GetClock()
+	s.rand = s.config.GetRand()
+	s.generators = make(map[string]internal.RecordGenerator)
+	// collections are created in a fixed order, so they draw the same random
+	// values with the same seed
+	collections := s.config.GetCollectionConfigs()
+	for _, collection := range slices.Sorted(maps.Keys(collections)) {
+		cfg := collections[collection]
+		evolution, err := cfg.SchemaEvolution()
+		if err != nil {
+			return fmt.Errorf("invalid schema evolution for collection %q: %w", collection, err)
+		}
+
 		var gen internal.RecordGenerator
-		var err error
 		switch cfg.Format.Type {
 		case FormatTypeFile:
-			gen, err = internal.NewFileRecordGenerator(collection, cfg.SdkOperations(), cfg.Format.FileOptionsPath)
+			gen, err = internal.NewFileRecordGenerator(s.rand, collection, cfg.SdkOperations(), cfg.Format.FileOptionsPath)
 		case FormatTypeRaw:
-			gen, err = internal.NewRawRecordGenerator(collection, cfg.SdkOperations(), cfg.Format.Options)
+			encoding := cfg.Format.RawEncoding()
+			encoding.AvroSubject = s.payloadSubject(collection)
+			gen, err = internal.NewRawRecordGenerator(ctx, s.rand, collection, cfg.SdkOperations(), cfg.Format.Options, evolution, encoding, cfg.Format.TargetPayloadSize())
 		case FormatTypeStructured:
-			gen, err = internal.NewStructuredRecordGenerator(collection, cfg.SdkOperations(), cfg.Format.Options)
+			gen, err = internal.NewStructuredRecordGenerator(s.rand, collection, cfg.SdkOperations(), cfg.Format.Options, evolution, cfg.Format.TargetPayloadSize())
+		case FormatTypeAvro:
+			gen, err = internal.NewAvroRecordGenerator(ctx, s.rand, collection, cfg.SdkOperations(), cfg.Format.FileOptionsPath, s.payloadSubject(collection))
+		case FormatTypeSQL:
+			gen, err = internal.NewSQLRecordGenerator(s.rand, cfg.SdkOperations(), cfg.Format.FileOptionsPath)
+		case FormatTypeProtobuf:
+			gen, err = internal.NewProtobufRecordGenerator(ctx, s.rand, collection, cfg.SdkOperations(), cfg.Format.FileOptionsPath, cfg.Format.ProtobufOptionsMessage, cfg.Format.ProtobufOptionsBinary)
+		case FormatTypeSample:
+			gen, err = newSampleRecordGenerator(s.rand, collection, cfg, evolution)
+		}
+		if err == nil {
+			gen, err = internal.WithCompression(
+				internal.WithEnvelope(gen, cfg.Format.Envelope),
+				cfg.Format.Compression,
+				cfg.Format.CompressionMetadata,
+			)
+		}
+		if err == nil {
+			eventTime := cfg.GetEventTime()
+			eventTime.Clock = s.clock
+			eventTime.Rand = s.rand
+			gen.SetEventTime(eventTime)
+			gen = internal.WithMalformed(gen, s.rand, cfg.GetMalformed())
+			if s.config.Mode == ModePool {
+				gen = internal.WithPool(gen, s.config.PoolSize)
+			}
 		}
 		if err != nil {
 			return fmt.Errorf("failed to create record generator for collection %q: %w", collection, err)
 		}
-		generators = append(generators, gen)
+		s.generators[collection] = gen
 	}
 
-	s.recordGenerator = internal.Combine(generators...)
-	if rl := s.config.RateLimit(); rl > 0 {
+	s.recordGenerator = internal.Combine(s.rand, s.activeGenerators(nil)...)
+	s.phases = s.config.GetPhases()
+	s.faults = s.config.GetFaultInjector()
+	s.faults.Rand = s.rand
+	if pos != nil {
+		p, err := internal.ParsePosition(pos)
+		if err != nil {
+			// positions of older versions don't contain any state
+			sdk.Logger(ctx).Warn().Err(err).Msg("could not restore generator state from position, starting from scratch")
+		} else {
+			for _, gen := range s.generators {
+				gen.Restore(p.Collections)
+			}
+			s.seq = p.Seq
+			if s.config.Seed != 0 {
+				// continue with other random values than after opening
+				s.rand.Seed(s.config.Seed + p.Seq)
+			}
+			s.recordCount = p.RecordCount
+			s.phase, s.phaseCount = p.Phase, p.PhaseCount
+			s.restoreRecent(p.Recent)
+			if s.clock != nil && p.Clock != nil {
+				s.clock.Resume(*p.Clock)
+			}
+			if p.Faults != nil {
+				s.faults.Restore(*p.Faults)
+			}
+		}
+	}
+	if s.config.Trace.Path != "" {
+		trace, err := internal.LoadTrace(s.config.Trace.Path, s.config.Trace.Format, s.config.Trace.Speed, s.config.Trace.Loop)
+		if err != nil {
+			return fmt.Errorf("failed to load trace: %w", err)
+		}
+		s.trace = trace
+	}
+	s.openedAt = s.wallClock.Now()
+	// We can safely ignore the error here, it has been validated.
+	s.schedule, _ = s.config.GetSchedule()
+	if s.config.MaxDuration > 0 {
+		stopAt := s.openedAt.Add(s.config.MaxDuration)
+		if s.schedule.StopAt.IsZero() || stopAt.Before(s.schedule.StopAt) {
+			s.schedule.StopAt = stopAt
+		}
+	}
+	s.faults.Prepare(s.recordCount)
+	s.acks = internal.NewAckTracker(s.wallClock)
+	s.lastAckLog = s.openedAt
+	if s.config.MaxInFlight > 0 {
+		s.inFlight = make(chan struct{}, s.config.MaxInFlight)
+	}
+	s.rateProfile = s.config.GetRateProfile()
+	s.arrival = s.config.GetArrivalModel()
+	if rl := s.config.RateLimit(); rl > 0 || s.rateProfile.Enabled() || len(s.phases) > 0 {
 		s.rateLimiter = rate.NewLimiter(rl, 1)
 	}
-	if s.config.Burst.SleepTime > 0 {
-		s.burstUntil = time.Now().Add(s.config.Burst.GenerateTime)
+	s.burst = s.config.Burst
+	if s.burst.SleepTime > 0 {
+		s.burstUntil = s.wallClock.Now().Add(s.burst.GenerateTime)
+	}
+	if s.phase < len(s.phases) {
+		s.startPhase()
 	}
 
 	return nil
 }
 
+// startPhase applies the rate, bursts, operations and collections of the
+// current phase. The duration of a phase restored from a position starts
+// over.
+func (s *Source) startPhase() {
+	p := s.phases[s.phase]
+	s.phaseStartedAt = s.wallClock.Now()
+
+	limit := p.RateLimit(s.config)
+	if limit == 0 {
+		limit = rate.Inf
+	}
+	s.rateLimiter.SetLimitAt(s.phaseStartedAt, limit)
+	s.burst = p.GetBurst(s.config)
+	if s.burst.SleepTime > 0 {
+		s.burstUntil = s.wallClock.Now().Add(s.burst.GenerateTime)
+	}
+
+	collections := s.config.GetCollectionConfigs()
+	for collection, gen := range s.generators {
+		operations := p.SdkOperations()
+		if operations == nil {
+			operations = collections[collection].SdkOperations()
+		}
+		gen.SetOperations(operations)
+	}
+	s.recordGenerator = internal.Combine(s.rand, s.activeGenerators(p.Collections)...)
+}
+
+// activeGenerators returns the generators of the given collections, or all
+// generators if collections is empty, sorted by collection.
+func (s *Source) activeGenerators(collections []string) []internal.RecordGenerator {
+	var active []internal.RecordGenerator
+	for _, collection := range slices.Sorted(maps.Keys(s.generators)) {
+		if len(collections) == 0 || slices.Contains(collections, collection) {
+			active = append(active, s.generators[collection])
+		}
+	}
+	return active
+}
+
+// nextPhase moves on to the next phase once the current phase ended. It
+// returns false if all phases ended.
+func (s *Source) nextPhase() bool {
+	for s.phase < len(s.phases) {
+		p := s.phases[s.phase]
+		ended := (p.RecordCount > 0 && s.phaseCount >= p.RecordCount) ||
+			(p.Duration > 0 && s.since(s.phaseStartedAt) >= p.Duration)
+		if !ended {
+			return true
+		}
+		s.phase++
+		s.phaseCount = 0
+		if s.phase < len(s.phases) {
+			s.startPhase()
+		}
+	}
+	return false
+}
+
+// newSampleRecordGenerator infers the fields from the sample file and creates
+// a generator producing structured records with these fields. The inferred
+// settings are written out if configured.
+func newSampleRecordGenerator(rnd *rand.Rand, collection string, cfg CollectionConfig, evolution internal.Evolution) (internal.RecordGenerator, error) {
+	inferred, err := InferCollectionConfig(cfg.Format.FileOptionsPath)
+	if err != nil {
+		return nil, err
+	}
+	inferred.Operations = cfg.Operations
+
+	if cfg.Format.SampleOptionsOutput != "" {
+		prefix := ""
+		if collection != "" {
+			prefix = "collections." + collection + "."
+		}
+		err := writeSettings(cfg.Format.SampleOptionsOutput, inferred.Settings(prefix))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return internal.NewStructuredRecordGenerator(rnd, collection, cfg.SdkOperations(), inferred.Format.Options, evolution, cfg.Format.TargetPayloadSize())
+}
+
+// payloadSubject returns the schema subject used for payload schemas in the
+// given collection, following the same naming as the SDK schema extraction.
+func (s *Source) payloadSubject(collection string) string {
+	subject := "payload"
+	if s.config.PayloadSubject != nil {
+		subject = *s.config.PayloadSubject
+	}
+	if collection != "" {
+		subject = collection + "." + subject
+	}
+	return subject
+}
+
 func (s *Source) Read(ctx context.Context) (opencdc.Record, error) {
+	recs, err := s.ReadN(ctx, 1)
+	if err != nil {
+		return opencdc.Record{}, err
+	}
+	return recs[0], nil
+}
+
+// ReadN generates up to n records at once and reserves the rate tokens for
+// all of them in one call, so high rates aren't limited by the overhead of
+// reading records one by one. The batch is cut short by the record count, the
+// record count of the current phase, the records allowed in flight and
+// injected faults. Records that were prepared but not emitted are emitted in
+// the next read.
+func (s *Source) ReadN(ctx context.Context, n int) ([]opencdc.Record, error) {
 	if ctx.Err() != nil {
 		// stop producing new records if context is canceled
-		return opencdc.Record{}, ctx.Err()
+		return nil, ctx.Err()
 	}
 
 	if s.config.RecordCount > 0 && s.recordCount >= s.config.RecordCount {
-		// nothing more to produce, block until context is done
-		<-ctx.Done()
-		return opencdc.Record{}, ctx.Err()
+		// nothing more to produce
+		return nil, s.endOfStream(ctx)
+	}
+	if len(s.phases) > 0 && !s.nextPhase() {
+		// all phases ended, nothing more to produce
+		return nil, s.endOfStream(ctx)
+	}
+	err := s.waitForSchedule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = s.faults.Check(s.recordCount, s.since(s.openedAt))
+	if err != nil {
+		sdk.Logger(ctx).Warn().Err(err).Msg("injected fault")
+		return nil, err
+	}
+
+	n, err = s.acquireInFlight(ctx, s.batchSize(n))
+	if err != nil {
+		return nil, err
+	}
+
+	// the trace determines when records are due instead of bursts and rates
+	var due time.Time
+	if s.trace != nil {
+		due, err = s.nextTraceDue(ctx)
+		if err != nil {
+			s.releaseInFlight()
+			return nil, err
+		}
+	}
+
+	// prepare next records in advance to avoid losing time in case of rate limiting
+	prepared := make([]preparedRecord, n)
+	for i := range prepared {
+		prepared[i] = s.nextRecord()
+	}
+
+	if s.trace != nil {
+		err = s.sleepUntil(ctx, due)
+	} else {
+		err = s.throttle(ctx, n)
+	}
+	if err != nil {
+		s.keepUnsent(prepared)
+		// the unsent record is still due at the same time
+		s.traceDue = due
+		return nil, err
+	}
+
+	recs := make([]opencdc.Record, 0, n)
+	for i, p := range prepared {
+		rec := p.record
+		switch {
+		case p.generated:
+			s.recordCount++
+			s.phaseCount++
+			s.redelivered = 0
+			s.remember(rec)
+			// the position contains the fault of the next read
+			s.faults.Prepare(s.recordCount)
+			rec.Position = s.position()
+			s.scheduleDuplicate(rec)
+		case len(rec.Position) == 0:
+			s.redelivered++
+			rec.Position = s.position()
+		}
+		s.acks.Emitted(rec.Position, s.seq)
+		recs = append(recs, rec)
+
+		if s.faults.Pending() {
+			// the fault is injected in the next read, before the rest of
+			// the batch
+			s.keepUnsent(prepared[i+1:])
+			break
+		}
 	}
+	return recs, nil
+}
 
-	// prepare next record in advance to avoid losing time in case of rate limiting
-	rec := s.recordGenerator.Next()
+// nextTraceDue returns the time the next record of the trace is due. If the
+// trace ended, the end of stream is handled.
+func (s *Source) nextTraceDue(ctx context.Context) (time.Time, error) {
+	if !s.traceDue.IsZero() {
+		due := s.traceDue
+		s.traceDue = time.Time{}
+		return due, nil
+	}
+	offset, ok := s.trace.Next()
+	if !ok {
+		// the trace ended, nothing more to produce
+		return time.Time{}, s.endOfStream(ctx)
+	}
+	return s.openedAt.Add(offset), nil
+}
 
+// preparedRecord is a record prepared for the next batch, generated is true
+// if it was newly generated.
+type preparedRecord struct {
+	record    opencdc.Record
+	generated bool
+}
+
+// keepUnsent stores the prepared records that were not emitted, so they are
+// emitted in the next read, and releases their slots of records in flight.
+func (s *Source) keepUnsent(prepared []preparedRecord) {
+	s.unsent = append(slices.Clone(prepared), s.unsent...)
+	for range prepared {
+		s.releaseInFlight()
+	}
+}
+
+// batchSize returns the number of records to generate in a batch of at most
+// n records. Traces and arrival models other than constant determine the time
+// of each record, so these records are generated one by one. A batch ends
+// before the fault injected after a number of records.
+func (s *Source) batchSize(n int) int {
+	if s.trace != nil || (s.rateLimiter != nil && !s.arrival.Constant()) {
+		return 1
+	}
+	if remaining := s.faults.Remaining(s.recordCount); remaining >= 0 {
+		n = min(n, remaining)
+	}
+	if s.config.RecordCount > 0 {
+		n = min(n, s.config.RecordCount-s.recordCount)
+	}
+	if len(s.phases) > 0 {
+		if p := s.phases[s.phase]; p.RecordCount > 0 {
+			n = min(n, p.RecordCount-s.phaseCount)
+		}
+	}
+	return max(n, 1)
+}
+
+// generatorState returns the state of the record generators of all
+// collections, including the ones not active in the current phase.
+func (s *Source) generatorState() map[string]internal.GeneratorState {
+	states := make(map[string]internal.GeneratorState, len(s.generators))
+	for _, gen := range s.generators {
+		maps.Copy(states, gen.State())
+	}
+	return states
+}
+
+// scheduledRecord is a duplicate that is emitted once it's due.
+type scheduledRecord struct {
+	due    time.Time
+	record opencdc.Record
+}
+
+// restoreRecent restores the records generated before the restart and
+// schedules them to be replayed. Each replayed record gets a new position.
+func (s *Source) restoreRecent(recent []opencdc.Record) {
+	if s.config.Duplicates.Replay == 0 {
+		return
+	}
+	for _, rec := range recent {
+		s.remember(rec)
+	}
+	for _, rec := range s.recent {
+		rec = rec.Clone()
+		if rec.Metadata == nil {
+			rec.Metadata = make(opencdc.Metadata)
+		}
+		rec.Metadata[internal.MetadataDuplicate] = "true"
+		s.replay = append(s.replay, rec)
+	}
+}
+
+// nextRecord returns the next record to emit, which is a record left unsent
+// by the last read, a replayed record, a due duplicate or a newly generated
+// record. Records without a position get one when they are emitted.
+func (s *Source) nextRecord() preparedRecord {
+	if len(s.unsent) > 0 {
+		p := s.unsent[0]
+		s.unsent = s.unsent[1:]
+		return p
+	}
+	if len(s.replay) > 0 {
+		rec := s.replay[0]
+		s.replay = s.replay[1:]
+		return preparedRecord{record: rec}
+	}
+	if len(s.duplicates) > 0 && !s.duplicates[0].due.After(s.wallClock.Now()) {
+		rec := s.duplicates[0].record
+		s.duplicates = s.duplicates[1:]
+		if !s.config.Duplicates.SamePosition {
+			rec.Position = nil
+		}
+		return preparedRecord{record: rec}
+	}
+	return preparedRecord{record: s.recordGenerator.Next(), generated: true}
+}
+
+// remember stores the newly generated record in the recent records, if
+// records are replayed after a restart. The oldest records are dropped once
+// there are more than configured or they exceed the maximum size.
+func (s *Source) remember(rec opencdc.Record) {
+	if s.config.Duplicates.Replay == 0 {
+		return
+	}
+	rec = rec.Clone()
+	rec.Position = nil
+	s.recent = append(s.recent, rec)
+	s.recentSizes = append(s.recentSizes, len(rec.Bytes()))
+
+	drop := max(len(s.recent)-s.config.Duplicates.Replay, 0)
+	if maxSize := s.config.GetReplayMaxSize(); maxSize > 0 {
+		size := 0
+		for _, n := range s.recentSizes[drop:] {
+			size += n
+		}
+		for ; drop < len(s.recent) && size > maxSize; drop++ {
+			size -= s.recentSizes[drop]
+		}
+	}
+	s.recent = s.recent[drop:]
+	s.recentSizes = s.recentSizes[drop:]
+}
+
+// scheduleDuplicate schedules a duplicate of the record with the configured
+// probability.
+func (s *Source) scheduleDuplicate(rec opencdc.Record) {
+	if s.config.Duplicates.Probability > 0 && s.rand.Float64() < s.config.Duplicates.Probability {
+		dup := rec.Clone()
+		dup.Metadata[internal.MetadataDuplicate] = "true"
+		s.duplicates = append(s.duplicates, scheduledRecord{
+			due:    s.wallClock.Now().Add(s.config.Duplicates.Delay),
+			record: dup,
+		})
+	}
+}
+
+// position returns a new position with the next sequence number, capturing
+// the state of the generator.
+func (s *Source) position() opencdc.Position {
+	s.seq++
+	p := internal.Position{
+		Seq:         s.seq,
+		Collections: s.generatorState(),
+		RecordCount: s.recordCount,
+		Phase:       s.phase,
+		PhaseCount:  s.phaseCount,
+		Redelivered: s.redelivered,
+		Recent:      s.recent,
+	}
+	if s.clock != nil {
+		last := s.clock.Last()
+		p.Clock = &last
+	}
+	if st := s.faults.State(); st != (internal.FaultState{}) {
+		p.Faults = &st
+	}
+	return p.ToRecordPosition()
+}
+
+// acquireInFlight blocks until the number of records in flight is below the
+// limit and adds up to n records to the records in flight. It returns the
+// number of records added.
+func (s *Source) acquireInFlight(ctx context.Context, n int) (int, error) {
+	if s.inFlight == nil {
+		return n, nil
+	}
+	select {
+	case s.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	// the rest of the batch only takes the records that fit without blocking
+	for i := 1; i < n; i++ {
+		select {
+		case s.inFlight <- struct{}{}:
+		default:
+			return i, nil
+		}
+	}
+	return n, nil
+}
+
+// releaseInFlight removes a record from the records in flight.
+func (s *Source) releaseInFlight() {
+	select {
+	case <-s.inFlight:
+	default:
+		// more acks than records in flight, e.g. after a restart
+	}
+}
+
+// waitForSchedule blocks until the schedule allows generating records. If the
+// schedule ended, the end of stream is handled.
+func (s *Source) waitForSchedule(ctx context.Context) error {
+	for {
+		now := s.wallClock.Now()
+		if s.schedule.Ended(now) {
+			return s.endOfStream(ctx)
+		}
+		next := s.schedule.NextActive(now)
+		if !next.After(now) {
+			return nil
+		}
+		if !s.schedule.StopAt.IsZero() && s.schedule.StopAt.Before(next) {
+			next = s.schedule.StopAt
+		}
+		err := s.sleepUntil(ctx, next)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// endOfStream handles the end of the stream, after which no more records are
+// generated. Depending on the configuration it blocks until the context is
+// done or returns ErrEndOfStream.
+func (s *Source) endOfStream(ctx context.Context) error {
+	if s.config.EndOfStream == EndOfStreamError {
+		return ErrEndOfStream
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// throttle blocks until the next n records can be emitted according to the
+// burst and rate limiting configuration.
+func (s *Source) throttle(ctx context.Context, n int) error {
 	// bursts
-	if s.config.Burst.SleepTime > 0 {
+	if s.burst.SleepTime > 0 {
 		err := s.sleepBetweenBursts(ctx)
 		if err != nil {
-			return opencdc.Record{}, err
+			return err
 		}
 	}
 
 	// rate limiting
+	if s.rateProfile.Enabled() {
+		err := s.applyRateProfile(ctx)
+		if err != nil {
+			return err
+		}
+	}
 	if s.rateLimiter != nil {
-		err := s.rateLimiter.Wait(ctx)
+		if s.arrival.Constant() {
+			return s.waitN(ctx, n)
+		}
+		return s.waitForNextArrival(ctx)
+	}
+	return nil
+}
+
+// waitN blocks until the rate limiter allows n records. The limiter only
+// reserves up to its burst at once, so the burst is raised while waiting.
+func (s *Source) waitN(ctx context.Context, n int) error {
+	now := s.wallClock.Now()
+	if burst := s.rateLimiter.Burst(); n > burst {
+		s.rateLimiter.SetBurstAt(now, n)
+		defer func() {
+			s.rateLimiter.SetBurstAt(s.wallClock.Now(), burst)
+		}()
+	}
+	r := s.rateLimiter.ReserveN(now, n)
+	err := s.sleep(ctx, r.DelayFrom(now))
+	if err != nil {
+		// give back the tokens of records that are not emitted
+		r.CancelAt(s.wallClock.Now())
+	}
+	return err
+}
+
+// applyRateProfile sets the limit of the rate limiter to the current rate of
+// the rate profile. While the rate is close to 0 it waits for the rate to
+// increase, otherwise the next record could be delayed for a long time.
+func (s *Source) applyRateProfile(ctx context.Context) error {
+	for {
+		r := s.rateProfile.Rate(s.since(s.openedAt))
+		if r >= minProfileRate {
+			s.rateLimiter.SetLimitAt(s.wallClock.Now(), rate.Limit(r))
+			return nil
+		}
+		err := s.sleep(ctx, rateProfileTick)
 		if err != nil {
-			return opencdc.Record{}, err
+			return err
 		}
 	}
+}
 
-	s.recordCount++
-	return rec, nil
+// waitForNextArrival blocks until the next record is due according to the
+// arrival model and schedules the record after it. The mean rate is the limit
+// of the rate limiter, so it follows the rate profile.
+func (s *Source) waitForNextArrival(ctx context.Context) error {
+	limit := float64(s.rateLimiter.Limit())
+	now := s.wallClock.Now()
+	if s.nextArrival.IsZero() {
+		s.nextArrival = now
+	}
+	// Each record is scheduled after the due time of the previous one, even
+	// if it was read late, otherwise the mean rate would drop below the limit.
+	backlog := time.Duration(maxArrivalBacklog * float64(time.Second) / limit)
+	if earliest := now.Add(-backlog); s.nextArrival.Before(earliest) {
+		s.nextArrival = earliest
+	}
+	err := s.sleepUntil(ctx, s.nextArrival)
+	if err != nil {
+		return err
+	}
+	s.nextArrival = s.nextArrival.Add(s.arrival.Gap(s.rand, limit))
+	return nil
+}
+
+// since returns the time passed since t according to the wall clock.
+func (s *Source) since(t time.Time) time.Duration {
+	return s.wallClock.Now().Sub(t)
+}
+
+// sleepUntil blocks until the given time or until the context is done.
+func (s *Source) sleepUntil(ctx context.Context, t time.Time) error {
+	return s.sleep(ctx, t.Sub(s.wallClock.Now()))
+}
+
+// sleep blocks for the given duration or until the context is done.
+func (s *Source) sleep(ctx context.Context, dur time.Duration) error {
+	if dur <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.wallClock.After(dur):
+		return nil
+	}
 }
 
 func (s *Source) sleepBetweenBursts(ctx context.Context) error {
-	now := time.Now()
+	now := s.wallClock.Now()
 	if now.Before(s.burstUntil) {
 		return nil // no sleep needed
 	}
 
 	// Adjust the next burst time until it's in the future.
-	for s.burstUntil.Before(now) {
-		s.burstUntil = s.burstUntil.Add(s.config.Burst.SleepTime + s.config.Burst.GenerateTime)
+	for !now.Before(s.burstUntil) {
+		s.burstUntil = s.burstUntil.Add(s.burst.SleepTime + s.burst.GenerateTime)
 	}
 
 	// Check if we are in the sleep phase.
-	wakeAt := s.burstUntil.Add(-s.config.Burst.GenerateTime)
+	wakeAt := s.burstUntil.Add(-s.burst.GenerateTime)
 	dur := wakeAt.Sub(now)
 	if dur < 0 {
 		// We are in the generating phase, no need to sleep.
@@ -130,19 +833,81 @@ func (s *Source) sleepBetweenBursts(ctx context.Context) error {
 	}
 
 	// Block until the next burst window or context is done.
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-time.After(dur):
-		return nil
-	}
+	return s.sleep(ctx, dur)
 }
 
 func (s *Source) Ack(ctx context.Context, position opencdc.Position) error {
 	sdk.Logger(ctx).Debug().Str("position", string(position)).Msg("got ack")
-	return nil // no ack needed
+
+	violation := s.acks.Acked(position)
+	if violation == "" || violation == internal.AckOutOfOrder {
+		// the position was in flight
+		s.releaseInFlight()
+	}
+	s.logAckStats(ctx)
+	if violation == "" {
+		return nil
+	}
+
+	sdk.Logger(ctx).Warn().
+		Str("position", string(position)).
+		Str("violation", violation).
+		Msg("invalid ack")
+	if s.config.Acks.FailOnViolation {
+		return fmt.Errorf("%w: %s ack of position %s", ErrAckViolation, violation, position)
+	}
+	return nil
+}
+
+// logAckStats logs the ack statistics and the percentiles of the emit-to-ack
+// latency since the last log, if the log interval passed. Acks are handled in
+// a single goroutine.
+func (s *Source) logAckStats(ctx context.Context) {
+	if s.config.Acks.LogInterval <= 0 || s.since(s.lastAckLog) < s.config.Acks.LogInterval {
+		return
+	}
+	s.lastAckLog = s.wallClock.Now()
+	stats := s.acks.Stats()
+	latency := s.acks.IntervalLatency()
+	sdk.Logger(ctx).Info().
+		Int64("emitted", stats.Emitted).
+		Int64("acked", stats.Acked).
+		Int64("pending", stats.Missing).
+		Int64("unknown", stats.Unknown).
+		Int64("duplicate", stats.Duplicate).
+		Int64("outOfOrder", stats.OutOfOrder).
+		Int64("latency.count", latency.Count).
+		Dur("latency.p50", latency.P50).
+		Dur("latency.p95", latency.P95).
+		Dur("latency.p99", latency.P99).
+		Dur("latency.max", latency.Max).
+		Msg("ack statistics")
 }
 
-func (s *Source) Teardown(_ context.Context) error {
-	return nil // nothing to stop
+func (s *Source) Teardown(ctx context.Context) error {
+	if s.config.Faults.Teardown {
+		return &internal.FaultError{Kind: internal.FaultPermanent, Reason: "failed to tear down"}
+	}
+	if s.acks == nil {
+		return nil // not opened
+	}
+
+	stats := s.acks.Stats()
+	latency := s.acks.TotalLatency()
+	sdk.Logger(ctx).Info().
+		Int64("emitted", stats.Emitted).
+		Int64("acked", stats.Acked).
+		Int64("missing", stats.Missing).
+		Int64("unknown", stats.Unknown).
+		Int64("duplicate", stats.Duplicate).
+		Int64("outOfOrder", stats.OutOfOrder).
+		Dur("latency.p50", latency.P50).
+		Dur("latency.p95", latency.P95).
+		Dur("latency.p99", latency.P99).
+		Dur("latency.max", latency.Max).
+		Msg("ack statistics at teardown")
+	if s.config.Acks.FailOnViolation && stats.Violations() > 0 {
+		return fmt.Errorf("%w: %s", ErrAckViolation, stats)
+	}
+	return nil
 }
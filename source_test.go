@@ -15,16 +15,45 @@
 package generator
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
 	"maps"
+	"math"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 
+	"github.com/bufbuild/protocompile"
 	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-generator/internal"
 	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/conduitio/conduit-connector-sdk/schema"
+	"github.com/fxamacker/cbor/v2"
 	"github.com/goccy/go-json"
+	"github.com/hamba/avro/v2"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/matryer/is"
+	"github.com/pierrec/lz4/v4"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestSource_Read_RawData(t *testing.T) {
@@ -71,6 +100,223 @@ func TestSource_Read_RawData(t *testing.T) {
 	is.True(joined.After(now.Add(-time.Millisecond * 10)))
 }
 
+func TestSource_Read_RawEncoding(t *testing.T) {
+	cfg := map[string]string{
+		"format.type":         "raw",
+		"format.options.id":   "int(values=7)",
+		"format.options.name": "string(values=a;b)",
+		"operations":          "create",
+	}
+	decodeMap := func(unmarshal func([]byte, any) error) func(*is.I, []byte) {
+		return func(is *is.I, raw []byte) {
+			var m map[string]any
+			is.NoErr(unmarshal(raw, &m))
+			is.Equal(len(m), 2)
+			is.Equal(m["name"], "a;b")
+		}
+	}
+
+	testCases := []struct {
+		encoding string
+		settings map[string]string
+		verify   func(*is.I, []byte)
+	}{{
+		encoding: "csv",
+		verify: func(is *is.I, raw []byte) {
+			is.Equal(string(raw), "7,a;b\n")
+		},
+	}, {
+		encoding: "csv",
+		settings: map[string]string{"format.csv.delimiter": ";", "format.csv.header": "true"},
+		verify: func(is *is.I, raw []byte) {
+			is.Equal(string(raw), "id;name\n7;\"a;b\"\n")
+		},
+	}, {
+		encoding: "xml",
+		verify: func(is *is.I, raw []byte) {
+			is.Equal(string(raw), "<record><id>7</id><name>a;b</name></record>")
+		},
+	}, {
+		encoding: "msgpack",
+		verify:   decodeMap(msgpack.Unmarshal),
+	}, {
+		encoding: "cbor",
+		verify:   decodeMap(cbor.Unmarshal),
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.encoding, func(t *testing.T) {
+			is := is.New(t)
+			cfg := maps.Clone(cfg)
+			cfg["format.encoding"] = tc.encoding
+			maps.Copy(cfg, tc.settings)
+			underTest := openTestSource(t, cfg)
+
+			rec, err := underTest.Read(context.Background())
+			is.NoErr(err)
+			raw, ok := rec.Payload.After.(opencdc.RawData)
+			is.True(ok)
+			tc.verify(is, raw)
+		})
+	}
+}
+
+func TestSource_Read_RawAvro(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	cfg := map[string]string{
+		"collections.events.format.type":            "raw",
+		"collections.events.format.encoding":        "avro",
+		"collections.events.format.avro.confluent":  "true",
+		"collections.events.format.options.id":      "int",
+		"collections.events.format.options.name":    "string(null=0.5)",
+		"collections.events.format.options.created": "time",
+	}
+	underTest := openTestSource(t, cfg)
+
+	rec, err := underTest.Read(ctx)
+	is.NoErr(err)
+	raw := rec.Payload.After.(opencdc.RawData)
+
+	// the schema derived from the fields is registered
+	sch, err := schema.Get(ctx, "events.payload", 1)
+	is.NoErr(err)
+	is.Equal(raw[0], byte(0)) // magic byte
+	is.Equal(binary.BigEndian.Uint32(raw[1:5]), uint32(sch.ID))
+
+	avroSchema, err := avro.ParseBytes(sch.Bytes)
+	is.NoErr(err)
+	var got map[string]any
+	is.NoErr(avro.Unmarshal(avroSchema, raw[5:], &got))
+	is.Equal(len(got), 3)
+	_, ok := got["id"].(int64)
+	is.True(ok)
+	_, ok = got["created"].(time.Time)
+	is.True(ok)
+
+	// a configured schema ID is used as is
+	cfg["collections.events.format.avro.schemaID"] = "42"
+	underTest = openTestSource(t, cfg)
+	rec, err = underTest.Read(ctx)
+	is.NoErr(err)
+	raw = rec.Payload.After.(opencdc.RawData)
+	is.Equal(binary.BigEndian.Uint32(raw[1:5]), uint32(42))
+	is.NoErr(avro.Unmarshal(avroSchema, raw[5:], &got))
+}
+
+func TestSource_Read_Envelope(t *testing.T) {
+	readEnvelope := func(is *is.I, cfg map[string]string) (opencdc.Record, map[string]any) {
+		is.Helper()
+		underTest := openTestSource(t, cfg)
+		rec, err := underTest.Read(context.Background())
+		is.NoErr(err)
+		is.Equal(rec.Payload.Before, nil)
+
+		var doc map[string]any
+		is.NoErr(json.Unmarshal(rec.Payload.After.Bytes(), &doc))
+		return rec, doc
+	}
+
+	t.Run("debezium", func(t *testing.T) {
+		is := is.New(t)
+		rec, doc := readEnvelope(is, map[string]string{
+			"collections.users.format.type":         "structured",
+			"collections.users.format.options.id":   "int",
+			"collections.users.format.options.name": "string",
+			"collections.users.format.envelope":     "debezium",
+			"collections.users.operations":          "update",
+		})
+		is.Equal(rec.Operation, opencdc.OperationUpdate)
+		is.Equal(doc["op"], "u")
+		is.Equal(len(doc["before"].(map[string]any)), 2)
+		is.Equal(len(doc["after"].(map[string]any)), 2)
+		is.Equal(doc["source"].(map[string]any)["table"], "users")
+		is.True(doc["ts_ms"].(float64) > 0)
+	})
+
+	t.Run("maxwell", func(t *testing.T) {
+		is := is.New(t)
+		_, doc := readEnvelope(is, map[string]string{
+			"format.type":       "raw",
+			"format.options.id": "int",
+			"format.envelope":   "maxwell",
+			"operations":        "delete",
+		})
+		is.Equal(doc["type"], "delete")
+		is.Equal(doc["database"], "generator")
+		_, ok := doc["data"].(map[string]any)["id"].(float64)
+		is.True(ok)
+	})
+
+	t.Run("canal", func(t *testing.T) {
+		is := is.New(t)
+		rec, doc := readEnvelope(is, map[string]string{
+			"format.type":         "sql",
+			"format.options.path": "./testdata/shop.sql",
+			"format.envelope":     "canal",
+			"operations":          "snapshot",
+		})
+		collection, _ := rec.Metadata.GetCollection()
+		is.Equal(doc["table"], collection)
+		is.Equal(doc["type"], "INSERT")
+		is.Equal(doc["pkNames"], []any{"id"})
+		is.Equal(len(doc["data"].([]any)), 1)
+	})
+}
+
+func TestSource_Read_PayloadSize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		settings map[string]string
+		min, max int
+	}{{
+		name:     "structured",
+		settings: map[string]string{"format.type": "structured", "format.payloadSize": "2KB"},
+		min:      2048, max: 2048,
+	}, {
+		name:     "raw",
+		settings: map[string]string{"format.type": "raw", "format.payloadSize": "300"},
+		min:      300, max: 300,
+	}, {
+		name:     "csv",
+		settings: map[string]string{"format.type": "raw", "format.encoding": "csv", "format.csv.header": "true", "format.payloadSize": "1KB"},
+		min:      1024, max: 1024,
+	}, {
+		name:     "avro",
+		settings: map[string]string{"format.type": "raw", "format.encoding": "avro", "format.payloadSize": "uniform(1KB,4KB)"},
+		min:      1024, max: 4096,
+	}, {
+		name:     "lognormal",
+		settings: map[string]string{"format.type": "structured", "format.payloadSize": "lognormal(1KB,0.5)"},
+		min:      0, max: math.MaxInt,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			cfg := map[string]string{
+				"format.options.id":   "int",
+				"format.options.name": "string(null=0.5)",
+				"operations":          "create,update,delete",
+			}
+			maps.Copy(cfg, tc.settings)
+			underTest := openTestSource(t, cfg)
+
+			for range 20 {
+				rec, err := underTest.Read(context.Background())
+				is.NoErr(err)
+				data := rec.Payload.After
+				if data == nil {
+					data = rec.Payload.Before
+				}
+				size := len(data.Bytes())
+				is.True(size >= tc.min && size <= tc.max)
+				is.Equal(rec.Metadata["generator.payloadSize"], strconv.Itoa(size))
+			}
+		})
+	}
+}
+
 func TestSource_Read_PayloadFile(t *testing.T) {
 	is := is.New(t)
 	underTest := openTestSource(
@@ -94,6 +340,72 @@ func TestSource_Read_PayloadFile(t *testing.T) {
 	is.Equal(expected, v.Bytes())
 }
 
+func TestSource_Read_Compression(t *testing.T) {
+	want, err := os.ReadFile("./source_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		codec      string
+		decompress func([]byte) ([]byte, error)
+	}{{
+		codec: "gzip",
+		decompress: func(b []byte) ([]byte, error) {
+			r, err := gzip.NewReader(bytes.NewReader(b))
+			if err != nil {
+				return nil, err
+			}
+			return io.ReadAll(r)
+		},
+	}, {
+		codec: "snappy",
+		decompress: func(b []byte) ([]byte, error) {
+			return snappy.Decode(nil, b)
+		},
+	}, {
+		codec: "zstd",
+		decompress: func(b []byte) ([]byte, error) {
+			r, err := zstd.NewReader(bytes.NewReader(b))
+			if err != nil {
+				return nil, err
+			}
+			defer r.Close()
+			return io.ReadAll(r)
+		},
+	}, {
+		codec: "lz4",
+		decompress: func(b []byte) ([]byte, error) {
+			return io.ReadAll(lz4.NewReader(bytes.NewReader(b)))
+		},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.codec, func(t *testing.T) {
+			is := is.New(t)
+			underTest := openTestSource(t, map[string]string{
+				"format.type":                 "file",
+				"format.options.path":         "./source_test.go",
+				"format.compression":          tc.codec,
+				"format.compression.metadata": "true",
+				"operations":                  "create",
+			})
+
+			for range 2 {
+				rec, err := underTest.Read(context.Background())
+				is.NoErr(err)
+				is.Equal(rec.Metadata["generator.compression"], tc.codec)
+
+				raw := rec.Payload.After.(opencdc.RawData)
+				is.True(len(raw) < len(want))
+				got, err := tc.decompress(raw)
+				is.NoErr(err)
+				is.Equal(got, want)
+			}
+		})
+	}
+}
+
 func TestSource_Read_StructuredData(t *testing.T) {
 	is := is.New(t)
 	underTest := openTestSource(
@@ -132,6 +444,447 @@ func TestSource_Read_StructuredData(t *testing.T) {
 	is.True(joined.After(now.Add(-time.Millisecond * 10)))
 }
 
+func TestSource_Read_IntBounds(t *testing.T) {
+	is := is.New(t)
+	underTest := openTestSource(
+		t,
+		map[string]string{
+			"format.type":          "structured",
+			"format.options.wide":  "int(min=-9e18,max=9e18)",
+			"format.options.full":  "int(min=-1e19,max=1e19)",
+			"format.options.large": "int(min=9e18)",
+			"format.options.small": "int(min=-3,max=3)",
+			"operations":           "create",
+		},
+	)
+
+	for range 100 {
+		rec, err := underTest.Read(context.Background())
+		is.NoErr(err)
+		v := rec.Payload.After.(opencdc.StructuredData)
+
+		wide := v["wide"].(int)
+		is.True(wide >= -9e18 && wide <= 9e18)
+		large := v["large"].(int)
+		is.True(large >= 9e18)
+		small := v["small"].(int)
+		is.True(small >= -3 && small <= 3)
+		_, ok := v["full"].(int)
+		is.True(ok)
+	}
+}
+
+func TestSource_Read_Avro(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	underTest := openTestSource(
+		t,
+		map[string]string{
+			"collections.users.format.type":         "avro",
+			"collections.users.format.options.path": "./testdata/user.avsc",
+			"collections.users.operations":          "create,update",
+		},
+	)
+
+	for range 100 {
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+
+		subject, err := rec.Metadata.GetPayloadSchemaSubject()
+		is.NoErr(err)
+		is.Equal(subject, "users.payload")
+		version, err := rec.Metadata.GetPayloadSchemaVersion()
+		is.NoErr(err)
+
+		v, ok := rec.Payload.After.(opencdc.StructuredData)
+		is.True(ok)
+		is.Equal(len(v), 19)
+
+		// the generated payload needs to be encodable with the attached schema
+		sch, err := schema.Get(ctx, subject, version)
+		is.NoErr(err)
+		srd, err := sch.Serde()
+		is.NoErr(err)
+		_, err = srd.Marshal(v)
+		is.NoErr(err)
+	}
+}
+
+func TestSource_Read_SQL(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	underTest := openTestSource(
+		t,
+		map[string]string{
+			"format.type":         "sql",
+			"format.options.path": "./testdata/shop.sql",
+			"operations":          "create",
+		},
+	)
+
+	customers := 0
+	for i := range 200 {
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+
+		collection, err := rec.Metadata.GetCollection()
+		is.NoErr(err)
+		if i == 0 {
+			// orders reference customers, so a customer is generated first
+			is.Equal(collection, "customers")
+		}
+		v, ok := rec.Payload.After.(opencdc.StructuredData)
+		is.True(ok)
+		key, ok := rec.Key.(opencdc.StructuredData)
+		is.True(ok)
+		is.Equal(key, opencdc.StructuredData{"id": v["id"]})
+
+		switch collection {
+		case "customers":
+			customers++
+			is.Equal(len(v), 5)
+			is.Equal(v["id"], customers)
+			is.True(len(v["email"].(string)) <= 5)
+			if age, ok := v["age"].(int); ok {
+				is.True(age >= 18 && age < 100)
+			} else {
+				is.Equal(v["age"], nil)
+			}
+			is.True(v["tier"] == "free" || v["tier"] == "pro")
+			_, ok = v["created_at"].(time.Time)
+			is.True(ok)
+		case "orders":
+			is.Equal(len(v), 5)
+			customerID := v["customer_id"].(int)
+			is.True(customerID >= 1 && customerID <= customers)
+			amount := v["amount"].(float64)
+			is.True(amount >= 0 && amount < 1e6)
+			quantity := v["quantity"].(int)
+			is.True(quantity >= 1 && quantity <= 10)
+		default:
+			t.Fatalf("unexpected collection %q", collection)
+		}
+	}
+}
+
+func TestSource_Read_SQLChanges(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	underTest := openTestSource(
+		t,
+		map[string]string{
+			"format.type":         "sql",
+			"format.options.path": "./testdata/shop.sql",
+			"operations":          "create,update,delete",
+		},
+	)
+
+	// rows maps each table to the number of existing rows, which have the
+	// keys 1 to rows
+	rows := map[string]int{}
+	ops := map[opencdc.Operation]int{}
+	for range 500 {
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+		collection, err := rec.Metadata.GetCollection()
+		is.NoErr(err)
+		ops[rec.Operation]++
+
+		id := rec.Key.(opencdc.StructuredData)["id"].(int)
+		switch rec.Operation {
+		case opencdc.OperationCreate:
+			rows[collection]++
+			is.Equal(id, rows[collection]) // creates use the next key
+		case opencdc.OperationUpdate:
+			is.True(id >= 1 && id <= rows[collection]) // updates change existing rows
+		case opencdc.OperationDelete:
+			is.Equal(id, rows[collection]) // deletes remove the last row
+			rows[collection]--
+		}
+
+		for _, data := range []opencdc.Data{rec.Payload.Before, rec.Payload.After} {
+			if data == nil {
+				continue
+			}
+			v := data.(opencdc.StructuredData)
+			is.Equal(v["id"], id)
+			if collection == "orders" {
+				// orders only reference existing customers
+				customerID := v["customer_id"].(int)
+				is.True(customerID >= 1 && customerID <= rows["customers"])
+			}
+		}
+	}
+	is.True(ops[opencdc.OperationUpdate] > 0)
+	is.True(ops[opencdc.OperationDelete] > 0)
+}
+
+func TestSource_Open_SQLInvalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		ddl     string
+		wantErr string
+	}{{
+		name:    "empty integer range",
+		ddl:     "CREATE TABLE t (x INT CHECK (x > 1.2 AND x < 1.8));",
+		wantErr: `table "t": column "x": CHECK constraints don't allow any int value`,
+	}, {
+		name: "cyclic references",
+		ddl: `CREATE TABLE a (id INT PRIMARY KEY, b_id INT REFERENCES b (id));
+			CREATE TABLE b (id INT PRIMARY KEY, a_id INT REFERENCES a (id));`,
+		wantErr: `table "a": cyclic foreign key references`,
+	}, {
+		name: "reference to string key",
+		ddl: `CREATE TABLE a (code TEXT PRIMARY KEY);
+			CREATE TABLE b (id INT PRIMARY KEY, a_code TEXT REFERENCES a (code));`,
+		wantErr: `table "b": column "a_code" can only reference an integer primary key of table "a"`,
+	}, {
+		name: "reference to other column",
+		ddl: `CREATE TABLE a (id INT PRIMARY KEY, n INT);
+			CREATE TABLE b (id INT PRIMARY KEY, a_n INT REFERENCES a (n));`,
+		wantErr: `table "b": column "a_n" can only reference an integer primary key of table "a"`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "ddl.sql")
+			is.NoErr(os.WriteFile(path, []byte(tc.ddl), 0o600))
+
+			s := &Source{}
+			err := sdk.Util.ParseConfig(
+				ctx,
+				map[string]string{
+					"format.type":         "sql",
+					"format.options.path": path,
+				},
+				s.Config(),
+				Connector.NewSpecification().SourceParams,
+			)
+			is.NoErr(err)
+			err = s.Open(ctx, nil)
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tc.wantErr))
+		})
+	}
+}
+
+func TestSource_Read_Protobuf(t *testing.T) {
+	ctx := context.Background()
+
+	// compile the proto file, so we can decode binary messages and test
+	// loading a file descriptor set
+	compiled, err := (&protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{"./testdata"}}),
+	}).Compile(ctx, "order.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := compiled[0].Messages().ByName("Order")
+
+	fds := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range []protoreflect.FileDescriptor{timestamppb.File_google_protobuf_timestamp_proto, compiled[0]} {
+		fds.File = append(fds.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	fdsBytes, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fdsPath := filepath.Join(t.TempDir(), "order.binpb")
+	if err := os.WriteFile(fdsPath, fdsBytes, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"./testdata/order.proto", fdsPath} {
+		t.Run("structured "+filepath.Ext(path), func(t *testing.T) {
+			is := is.New(t)
+			underTest := openTestSource(
+				t,
+				map[string]string{
+					"format.type":            "protobuf",
+					"format.options.path":    path,
+					"format.options.message": "shop.v1.Order",
+				},
+			)
+
+			rec, err := underTest.Read(ctx)
+			is.NoErr(err)
+
+			v, ok := rec.Payload.After.(opencdc.StructuredData)
+			is.True(ok)
+			_, ok = v["id"].(int64)
+			is.True(ok)
+			is.True(v["status"] == "STATUS_UNSPECIFIED" || v["status"] == "STATUS_OPEN" || v["status"] == "STATUS_SHIPPED")
+			_, ok = v["items"].([]any)
+			is.True(ok)
+			_, ok = v["created_at"].(time.Time)
+			is.True(ok)
+			_, hasCard := v["card_number"]
+			_, hasIBAN := v["iban"]
+			is.True(hasCard != hasIBAN) // expected exactly one oneof field
+		})
+	}
+
+	t.Run("binary", func(t *testing.T) {
+		is := is.New(t)
+		underTest := openTestSource(
+			t,
+			map[string]string{
+				"format.type":            "protobuf",
+				"format.options.path":    fdsPath,
+				"format.options.message": "shop.v1.Order",
+				"format.options.binary":  "true",
+			},
+		)
+
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+
+		v, ok := rec.Payload.After.(opencdc.RawData)
+		is.True(ok)
+		msg := dynamicpb.NewMessage(md)
+		is.NoErr(proto.Unmarshal(v.Bytes(), msg))
+		is.True(msg.Get(md.Fields().ByName("customer")).String() != "")
+	})
+
+	t.Run("ambiguous message", func(t *testing.T) {
+		is := is.New(t)
+		s := &Source{}
+		err := sdk.Util.ParseConfig(
+			ctx,
+			map[string]string{
+				"format.type":         "protobuf",
+				"format.options.path": "./testdata/order.proto",
+			},
+			s.Config(),
+			Connector.NewSpecification().SourceParams,
+		)
+		is.NoErr(err)
+		err = s.Open(ctx, nil)
+		is.True(err != nil)
+	})
+}
+
+func TestSource_Read_SchemaEvolution(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	cfg := map[string]string{
+		"format.type":         "structured",
+		"format.options.id":   "int",
+		"format.options.name": "string",
+		"operations":          "create",
+		"evolution.records":   "2",
+		"evolution.steps":     "add:email:string,widen:id:string,rename:name:fullName,drop:email",
+	}
+	underTest := openTestSource(t, cfg)
+
+	wantFields := [][]string{
+		{"id", "name"},
+		{"email", "id", "name"},
+		{"email", "id", "name"},
+		{"email", "fullName", "id"},
+		{"fullName", "id"},
+	}
+	var positions []opencdc.Position
+	for i, want := range wantFields {
+		for range 2 {
+			rec, err := underTest.Read(ctx)
+			is.NoErr(err)
+			positions = append(positions, rec.Position)
+
+			data := rec.Payload.After.(opencdc.StructuredData)
+			is.Equal(slices.Sorted(maps.Keys(data)), want)
+			_, isString := data["id"].(string)
+			is.Equal(isString, i >= 2) // id is widened to a string in version 2
+		}
+	}
+
+	// restarting at a position continues with the same schema version
+	restarted := openTestSourceAt(t, cfg, positions[6])
+	rec, err := restarted.Read(ctx)
+	is.NoErr(err)
+	data := rec.Payload.After.(opencdc.StructuredData)
+	is.Equal(slices.Sorted(maps.Keys(data)), []string{"email", "fullName", "id"})
+	is.Equal(rec.Position, positions[7])
+}
+
+func TestSource_Read_Restart(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("sql", func(t *testing.T) {
+		is := is.New(t)
+		cfg := map[string]string{
+			"format.type":         "sql",
+			"format.options.path": "./testdata/shop.sql",
+			"operations":          "create",
+		}
+
+		// primary keys continue after a restart and stay unique
+		ids := map[string]map[any]bool{"customers": {}, "orders": {}}
+		read := func(underTest sdk.Source) opencdc.Position {
+			var pos opencdc.Position
+			for range 20 {
+				rec, err := underTest.Read(ctx)
+				is.NoErr(err)
+				collection, err := rec.Metadata.GetCollection()
+				is.NoErr(err)
+				v := rec.Payload.After.(opencdc.StructuredData)
+				is.True(!ids[collection][v["id"]])
+				ids[collection][v["id"]] = true
+				if collection == "orders" {
+					is.True(ids["customers"][v["customer_id"]])
+				}
+				pos = rec.Position
+			}
+			return pos
+		}
+		pos := read(openTestSource(t, cfg))
+		read(openTestSourceAt(t, cfg, pos))
+		is.Equal(len(ids["customers"])+len(ids["orders"]), 40)
+	})
+
+	t.Run("recordCount", func(t *testing.T) {
+		is := is.New(t)
+		cfg := map[string]string{
+			"recordCount":       "5",
+			"endOfStream":       "error",
+			"format.type":       "raw",
+			"format.options.id": "int",
+		}
+		underTest := openTestSource(t, cfg)
+		var pos opencdc.Position
+		for range 3 {
+			rec, err := underTest.Read(ctx)
+			is.NoErr(err)
+			pos = rec.Position
+		}
+
+		// the records generated before the restart count towards the limit
+		restarted := openTestSourceAt(t, cfg, pos)
+		for range 2 {
+			_, err := restarted.Read(ctx)
+			is.NoErr(err)
+		}
+		_, err := restarted.Read(ctx)
+		is.Equal(err, ErrEndOfStream)
+	})
+}
+
+func TestSource_Open_LegacyPosition(t *testing.T) {
+	is := is.New(t)
+	underTest := openTestSourceAt(t, map[string]string{
+		"format.type":       "raw",
+		"format.options.id": "int",
+	}, opencdc.Position("12"))
+
+	rec, err := underTest.Read(context.Background())
+	is.NoErr(err)
+	pos, err := internal.ParsePosition(rec.Position)
+	is.NoErr(err)
+	is.Equal(pos.Collections[""].Count, 1)
+}
+
 func TestSource_Read_RateLimit(t *testing.T) {
 	cfg := map[string]string{
 		"burst.sleepTime":    "100ms",
@@ -155,68 +908,1507 @@ func TestSource_Read_RateLimit(t *testing.T) {
 	})
 }
 
-func testSourceRateLimit(t *testing.T, cfg map[string]string) {
-	ctx := context.Background()
+func TestSource_Read_RateLimitExact(t *testing.T) {
+	cfg := map[string]string{
+		"burst.sleepTime":    "100ms",
+		"burst.generateTime": "150ms",
+		"format.type":        "raw",
+		"format.options.id":  "int",
+		"operations":         "create,update",
+	}
 
-	underTest := openTestSource(t, cfg)
+	t.Run("parameter-rate", func(t *testing.T) {
+		cfg := maps.Clone(cfg)
+		cfg["rate"] = "20"
+		testSourceRateLimitExact(t, cfg)
+	})
+	t.Run("parameter-readTime", func(t *testing.T) {
+		cfg := maps.Clone(cfg)
+		cfg["readTime"] = "50ms"
+		testSourceRateLimitExact(t, cfg)
+	})
+}
 
-	const epsilon = time.Millisecond * 10
-	readAssertDelay := func(is *is.I, expectedDelay time.Duration) {
-		is.Helper()
-		start := time.Now()
+func TestSource_Read_RateProfile(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	clock := newFakeClock()
+	underTest := openTestSourceWithClock(t, map[string]string{
+		"rate.profile":      "step",
+		"rate.steps":        "1000,10",
+		"rate.period":       "100ms",
+		"format.type":       "raw",
+		"format.options.id": "int",
+	}, clock)
+
+	// the first step limits the rate to 1000 records per second
+	start := clock.Now()
+	for range 20 {
 		_, err := underTest.Read(ctx)
-		dur := time.Since(start)
 		is.NoErr(err)
-		is.True(dur >= expectedDelay-epsilon) // expected longer delay
-		is.True(dur <= expectedDelay+epsilon) // expected shorter delay
 	}
+	is.True(isAbout(clock.Now().Sub(start), 19*time.Millisecond))
 
-	is := is.New(t)
+	// the second step limits the rate to 10 records per second
+	clock.Advance(100*time.Millisecond - clock.Now().Sub(start))
+	start = clock.Now()
+	for range 3 {
+		_, err := underTest.Read(ctx)
+		is.NoErr(err)
+	}
+	is.True(isAbout(clock.Now().Sub(start), 200*time.Millisecond))
+}
 
-	// We start in the generate cycle, we can test the rate limiting here.
-	// The first record should be read immediately.
-	readAssertDelay(is, 0)
+func TestSource_Read_Arrival(t *testing.T) {
+	const (
+		n    = 20000
+		mean = 2 * time.Millisecond // rate of 500 records per second
+	)
+	// readGaps reads n records and returns the time between them, the
+	// consumer takes the given time to process each record
+	readGaps := func(is *is.I, arrival string, processing time.Duration) []time.Duration {
+		ctx := context.Background()
+		clock := newFakeClock()
+		underTest := openTestSourceWithClock(t, map[string]string{
+			"rate":              "500",
+			"rate.arrival":      arrival,
+			"rate.jitter":       "1",
+			"format.type":       "raw",
+			"format.options.id": "int",
+		}, clock)
 
-	// The second record should already be rate limited and delayed by 50ms.
-	readAssertDelay(is, 50*time.Millisecond)
+		gaps := make([]time.Duration, n)
+		last := clock.Now()
+		for i := range n {
+			_, err := underTest.Read(ctx)
+			is.NoErr(err)
+			gaps[i] = clock.Now().Sub(last)
+			last = clock.Advance(processing)
+		}
+		return gaps
+	}
+	meanOf := func(gaps []time.Duration) time.Duration {
+		var total time.Duration
+		for _, g := range gaps {
+			total += g
+		}
+		return total / time.Duration(len(gaps))
+	}
+	// fraction returns the fraction of gaps shorter than d
+	fraction := func(gaps []time.Duration, d time.Duration) float64 {
+		shorter := 0
+		for _, g := range gaps {
+			if g < d {
+				shorter++
+			}
+		}
+		return float64(shorter) / float64(len(gaps))
+	}
 
-	// If we wait for 50ms before reading, the next record should be read immediately.
-	time.Sleep(50 * time.Millisecond)
-	readAssertDelay(is, 0)
+	t.Run("poisson", func(t *testing.T) {
+		is := is.New(t)
+		gaps := readGaps(is, "poisson", 0)
 
-	// If we wait for 25ms, the next record should be read after 25ms.
-	time.Sleep(25 * time.Millisecond)
-	readAssertDelay(is, 25*time.Millisecond)
+		// the gaps are exponentially distributed with the configured mean,
+		// the first record is emitted right away
+		is.True(math.Abs(float64(meanOf(gaps[1:])-mean)) < 0.03*float64(mean))
+		is.True(math.Abs(fraction(gaps[1:], mean)-(1-1/math.E)) < 0.03)
+		is.True(math.Abs(fraction(gaps[1:], 2*mean)-(1-1/(math.E*math.E))) < 0.03)
+	})
 
-	// By now we should have reached the end of burst.generateTime (150ms).
-	// If we try to read a record now we should have to wait for 100ms (burst.sleepTime).
-	readAssertDelay(is, 100*time.Millisecond)
+	t.Run("uniform", func(t *testing.T) {
+		is := is.New(t)
+		gaps := readGaps(is, "uniform", 0)
 
-	// After the sleep cycle we are again in the generate cycle. Reading a record
-	// should have the normal delay of 50ms.
-	readAssertDelay(is, 50*time.Millisecond)
+		// the gaps are uniformly distributed between 0 and twice the mean
+		is.True(math.Abs(float64(meanOf(gaps[1:])-mean)) < 0.03*float64(mean))
+		is.True(slices.Max(gaps[1:]) <= 2*mean)
+		is.True(math.Abs(fraction(gaps[1:], mean/2)-0.25) < 0.03)
+		is.True(math.Abs(fraction(gaps[1:], mean)-0.5) < 0.03)
+	})
 
-	// Wait for 100ms (remaining generate time) + 50ms (half of sleep time) = 150ms,
-	// so we are in the middle of the sleep cycle. Reading at that point should
-	// take 50ms.
-	time.Sleep(150 * time.Millisecond)
-	readAssertDelay(is, 50*time.Millisecond)
+	for _, arrival := range []string{"poisson", "uniform"} {
+		t.Run(arrival+" with slow consumer", func(t *testing.T) {
+			is := is.New(t)
+			// short gaps are caught up on, so the mean rate is kept even if
+			// reading takes half of the mean gap
+			gaps := readGaps(is, arrival, mean/2)
+			is.True(math.Abs(float64(meanOf(gaps)+mean/2-mean)) < 0.03*float64(mean))
+		})
+	}
 }
 
-func openTestSource(t *testing.T, cfgMap map[string]string) sdk.Source {
-	is := is.New(t)
-	ctx := context.Background()
+func TestSource_Read_Trace(t *testing.T) {
+	readAll := func(is *is.I, underTest sdk.Source, n int) time.Duration {
+		is.Helper()
+		start := time.Now()
+		for range n {
+			_, err := underTest.Read(context.Background())
+			is.NoErr(err)
+		}
+		return time.Since(start)
+	}
+	assertEnded := func(is *is.I, underTest sdk.Source) {
+		is.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := underTest.Read(ctx)
+		is.Equal(err, context.DeadlineExceeded)
+	}
 
-	s := &Source{}
-	t.Cleanup(func() {
-		_ = s.Teardown(ctx)
+	t.Run("timestamps", func(t *testing.T) {
+		is := is.New(t)
+		underTest := openTestSource(t, map[string]string{
+			"trace.path":        "./testdata/trace_timestamps.txt",
+			"trace.speed":       "10",
+			"format.type":       "raw",
+			"format.options.id": "int",
+		})
+
+		// the trace spans 2 seconds, sped up 10 times
+		dur := readAll(is, underTest, 5)
+		is.True(dur >= 190*time.Millisecond)
+		is.True(dur < 250*time.Millisecond)
+		assertEnded(is, underTest)
 	})
 
-	err := sdk.Util.ParseConfig(ctx, cfgMap, s.Config(), Connector.NewSpecification().SourceParams)
-	is.NoErr(err)
+	t.Run("counts", func(t *testing.T) {
+		is := is.New(t)
+		underTest := openTestSource(t, map[string]string{
+			"trace.path":        "./testdata/trace_counts.csv",
+			"trace.format":      "counts",
+			"trace.speed":       "10",
+			"format.type":       "raw",
+			"format.options.id": "int",
+		})
 
-	err = s.Open(ctx, nil)
-	is.NoErr(err)
+		// the last second starts after 200ms
+		dur := readAll(is, underTest, 10)
+		is.True(dur >= 270*time.Millisecond)
+		is.True(dur < 330*time.Millisecond)
+		assertEnded(is, underTest)
+	})
 
-	return s
+	t.Run("loop", func(t *testing.T) {
+		is := is.New(t)
+		underTest := openTestSource(t, map[string]string{
+			"trace.path":        "./testdata/trace_timestamps.txt",
+			"trace.speed":       "20",
+			"trace.loop":        "true",
+			"format.type":       "raw",
+			"format.options.id": "int",
+		})
+
+		// the second iteration starts after the average gap (0.5s)
+		dur := readAll(is, underTest, 6)
+		is.True(dur >= 120*time.Millisecond)
+		is.True(dur < 170*time.Millisecond)
+	})
+
+	t.Run("interrupted reads", func(t *testing.T) {
+		is := is.New(t)
+		ctx := context.Background()
+		clock := newFakeClock()
+		underTest := openTestSourceWithClock(t, map[string]string{
+			"trace.path":        "./testdata/trace_timestamps.txt",
+			"trace.speed":       "10",
+			"maxInFlight":       "1",
+			"format.type":       "raw",
+			"format.options.id": "int",
+		}, clock)
+		start := clock.Now()
+
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+
+		// the read times out while waiting for a record in flight
+		readCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = underTest.Read(readCtx)
+		is.Equal(err, context.DeadlineExceeded)
+		is.NoErr(underTest.Ack(ctx, rec.Position))
+
+		// the read times out while waiting for the record to be due
+		clock.Stall(true)
+		readCtx, cancel = context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = underTest.Read(readCtx)
+		is.Equal(err, context.DeadlineExceeded)
+		clock.Stall(false)
+
+		// no record of the trace was skipped
+		for _, want := range []time.Duration{50, 100, 110, 200} {
+			rec, err = underTest.Read(ctx)
+			is.NoErr(err)
+			is.True(isAbout(clock.Now().Sub(start), want*time.Millisecond))
+			is.NoErr(underTest.Ack(ctx, rec.Position))
+		}
+		assertEnded(is, underTest)
+	})
+}
+
+func TestSource_Read_EndOfStream(t *testing.T) {
+	ctx := context.Background()
+	cfg := map[string]string{
+		"endOfStream":       "error",
+		"format.type":       "raw",
+		"format.options.id": "int",
+	}
+
+	t.Run("recordCount", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["recordCount"] = "2"
+		underTest := openTestSource(t, cfg)
+
+		for range 2 {
+			_, err := underTest.Read(ctx)
+			is.NoErr(err)
+		}
+		_, err := underTest.Read(ctx)
+		is.Equal(err, ErrEndOfStream)
+	})
+
+	t.Run("maxDuration", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["maxDuration"] = "100ms"
+		cfg["rate"] = "100"
+		underTest := openTestSource(t, cfg)
+
+		start := time.Now()
+		var n int
+		for {
+			_, err := underTest.Read(ctx)
+			if err != nil {
+				is.Equal(err, ErrEndOfStream)
+				break
+			}
+			n++
+		}
+		is.True(time.Since(start) >= 100*time.Millisecond)
+		is.True(n >= 9 && n <= 11)
+	})
+
+	t.Run("startAt and stopAt", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		startAt := time.Now().Add(100 * time.Millisecond)
+		cfg["startAt"] = startAt.Format(time.RFC3339Nano)
+		cfg["stopAt"] = startAt.Add(100 * time.Millisecond).Format(time.RFC3339Nano)
+		cfg["rate"] = "20"
+		underTest := openTestSource(t, cfg)
+
+		_, err := underTest.Read(ctx)
+		is.NoErr(err)
+		is.True(!time.Now().Before(startAt))
+
+		_, err = underTest.Read(ctx)
+		is.NoErr(err)
+		time.Sleep(100 * time.Millisecond)
+		_, err = underTest.Read(ctx)
+		is.Equal(err, ErrEndOfStream)
+	})
+
+	t.Run("windows", func(t *testing.T) {
+		is := is.New(t)
+		now := time.Now().UTC()
+		cfg := maps.Clone(cfg)
+		cfg["windows"] = fmt.Sprintf("%s-%s,%s-%s",
+			now.Add(-time.Hour).Format("15:04"), now.Add(time.Hour).Format("15:04"),
+			now.Add(2*time.Hour).Format("15:04"), now.Add(3*time.Hour).Format("15:04"),
+		)
+		underTest := openTestSource(t, cfg)
+
+		// the current time is in the first window
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := underTest.Read(ctx)
+		is.NoErr(err)
+	})
+}
+
+func TestSource_Read_Phases(t *testing.T) {
+	ctx := context.Background()
+	is := is.New(t)
+	cfg := map[string]string{
+		"endOfStream":                          "error",
+		"rate":                                 "20",
+		"collections.users.format.type":        "structured",
+		"collections.users.format.options.id":  "int",
+		"collections.users.operations":         "create",
+		"collections.orders.format.type":       "structured",
+		"collections.orders.format.options.id": "int",
+		"collections.orders.operations":        "create",
+		// snapshot of users at max speed
+		"phases.1.recordCount": "5",
+		"phases.1.rate":        "0",
+		"phases.1.operations":  "snapshot",
+		"phases.1.collections": "users",
+		// changes in all collections at the global rate
+		"phases.2.recordCount": "2",
+		"phases.2.operations":  "update,delete",
+		// spike of orders
+		"phases.10.duration":    "100ms",
+		"phases.10.rate":        "100",
+		"phases.10.collections": "orders",
+	}
+	clock := newFakeClock()
+	underTest := openTestSourceWithClock(t, cfg, clock)
+
+	start := clock.Now()
+	var last opencdc.Record
+	for range 5 {
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+		is.Equal(rec.Operation, opencdc.OperationSnapshot)
+		collection, _ := rec.Metadata.GetCollection()
+		is.Equal(collection, "users")
+		last = rec
+	}
+	is.Equal(clock.Now().Sub(start), time.Duration(0)) // snapshot should not be rate limited
+
+	p, err := internal.ParsePosition(last.Position)
+	is.NoErr(err)
+	is.Equal(p.Phase, 0)
+	is.Equal(p.PhaseCount, 5)
+
+	start = clock.Now()
+	for range 2 {
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+		is.True(rec.Operation == opencdc.OperationUpdate || rec.Operation == opencdc.OperationDelete)
+		last = rec
+	}
+	is.True(isAbout(clock.Now().Sub(start), 50*time.Millisecond)) // limited to 20/s
+
+	p, err = internal.ParsePosition(last.Position)
+	is.NoErr(err)
+	is.Equal(p.Phase, 1)
+
+	var n int
+	for {
+		rec, err := underTest.Read(ctx)
+		if err != nil {
+			is.Equal(err, ErrEndOfStream)
+			break
+		}
+		collection, _ := rec.Metadata.GetCollection()
+		is.Equal(collection, "orders")
+		is.Equal(rec.Operation, opencdc.OperationCreate)
+		n++
+	}
+	is.Equal(n, 10) // 100ms at 100/s
+
+	t.Run("restore", func(t *testing.T) {
+		is := is.New(t)
+		underTest := openTestSourceAt(t, cfg, internal.Position{Phase: 1, PhaseCount: 1}.ToRecordPosition())
+
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+		is.True(rec.Operation == opencdc.OperationUpdate || rec.Operation == opencdc.OperationDelete)
+		p, err := internal.ParsePosition(rec.Position)
+		is.NoErr(err)
+		is.Equal(p.Phase, 1)
+		is.Equal(p.PhaseCount, 2)
+	})
+}
+
+func TestSource_Faults(t *testing.T) {
+	ctx := context.Background()
+	cfg := map[string]string{
+		"format.type":       "raw",
+		"format.options.id": "int",
+	}
+
+	t.Run("afterRecords transient", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["faults.afterRecords"] = "2"
+		underTest := openTestSource(t, cfg)
+
+		var pos opencdc.Position
+		for range 2 {
+			rec, err := underTest.Read(ctx)
+			is.NoErr(err)
+			pos = rec.Position
+		}
+		// transient faults are retried by the SDK
+		_, err := underTest.Read(ctx)
+		is.True(errors.Is(err, ErrInjectedFault))
+		is.True(errors.Is(err, sdk.ErrBackoffRetry))
+
+		_, err = underTest.Read(ctx)
+		is.NoErr(err)
+
+		// the fault is not injected again after a restart
+		restarted := openTestSourceAt(t, cfg, pos)
+		_, err = restarted.Read(ctx)
+		is.NoErr(err)
+	})
+
+	t.Run("afterRecords permanent", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["faults.afterRecords"] = "2"
+		cfg["faults.kind"] = "permanent"
+		underTest := openTestSource(t, cfg)
+
+		var pos opencdc.Position
+		for range 2 {
+			rec, err := underTest.Read(ctx)
+			is.NoErr(err)
+			pos = rec.Position
+		}
+		// permanent faults stop the pipeline
+		_, err := underTest.Read(ctx)
+		is.True(errors.Is(err, ErrInjectedFault))
+		is.True(!errors.Is(err, sdk.ErrBackoffRetry))
+
+		// reads keep failing after a restart
+		restarted := openTestSourceAt(t, cfg, pos)
+		_, err = restarted.Read(ctx)
+		is.True(errors.Is(err, ErrInjectedFault))
+		is.True(!errors.Is(err, sdk.ErrBackoffRetry))
+	})
+
+	t.Run("at permanent", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["faults.at"] = "50ms"
+		cfg["faults.kind"] = "permanent"
+		clock := newFakeClock()
+		underTest := openTestSourceWithClock(t, cfg, clock)
+
+		_, err := underTest.Read(ctx)
+		is.NoErr(err)
+		clock.Advance(50 * time.Millisecond)
+		for range 2 {
+			_, err = underTest.Read(ctx)
+			is.True(errors.Is(err, ErrInjectedFault))
+			is.True(!errors.Is(err, sdk.ErrBackoffRetry))
+		}
+	})
+
+	t.Run("afterRecords in batch", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["faults.afterRecords"] = "5"
+		underTest := openTestSource(t, cfg)
+
+		// the batch ends before the fault
+		recs, err := underTest.ReadN(ctx, 100)
+		is.NoErr(err)
+		is.Equal(len(recs), 5)
+
+		_, err = underTest.ReadN(ctx, 100)
+		is.True(errors.Is(err, sdk.ErrBackoffRetry))
+
+		recs, err = underTest.ReadN(ctx, 100)
+		is.NoErr(err)
+		is.Equal(len(recs), 100)
+	})
+
+	t.Run("probability in batch", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["faults.probability"] = "0.05"
+		cfg["seed"] = "1"
+		underTest := openTestSource(t, cfg)
+
+		var seq int64
+		var faults int
+		for range 50 {
+			recs, err := underTest.ReadN(ctx, 10)
+			if err != nil {
+				is.True(errors.Is(err, sdk.ErrBackoffRetry))
+				faults++
+				continue
+			}
+			// records are not lost when a batch is cut short by a fault
+			for _, rec := range recs {
+				p, err := internal.ParsePosition(rec.Position)
+				is.NoErr(err)
+				is.Equal(p.Seq, seq+1)
+				seq = p.Seq
+			}
+			if len(recs) < 10 {
+				_, err = underTest.ReadN(ctx, 10)
+				is.True(errors.Is(err, sdk.ErrBackoffRetry))
+				faults++
+			}
+		}
+		is.True(faults > 0)
+	})
+
+	t.Run("permanent probability in batch", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["faults.probability"] = "0.05"
+		cfg["faults.kind"] = "permanent"
+		cfg["seed"] = "1"
+		underTest := openTestSource(t, cfg)
+
+		var recs []opencdc.Record
+		for {
+			batch, err := underTest.ReadN(ctx, 10)
+			if err != nil {
+				is.True(errors.Is(err, ErrInjectedFault))
+				break
+			}
+			recs = append(recs, batch...)
+		}
+		// no records are emitted after the fault was decided
+		for i, rec := range recs {
+			p, err := internal.ParsePosition(rec.Position)
+			is.NoErr(err)
+			failed := p.Faults != nil && p.Faults.Failed != ""
+			is.Equal(failed, i == len(recs)-1)
+		}
+	})
+
+	t.Run("probability", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["faults.probability"] = "0.5"
+		underTest := openTestSource(t, cfg)
+
+		var failed int
+		for range 1000 {
+			_, err := underTest.Read(ctx)
+			if err != nil {
+				is.True(errors.Is(err, ErrInjectedFault))
+				is.True(errors.Is(err, sdk.ErrBackoffRetry))
+				failed++
+			}
+		}
+		is.True(failed > 400 && failed < 600)
+	})
+
+	t.Run("open and teardown", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["faults.open"] = "true"
+		cfg["faults.teardown"] = "true"
+
+		s := &Source{}
+		err := sdk.Util.ParseConfig(ctx, cfg, s.Config(), Connector.NewSpecification().SourceParams)
+		is.NoErr(err)
+		is.True(errors.Is(s.Open(ctx, nil), ErrInjectedFault))
+		is.True(errors.Is(s.Teardown(ctx), ErrInjectedFault))
+	})
+}
+
+func TestSource_Read_Duplicates(t *testing.T) {
+	ctx := context.Background()
+	cfg := map[string]string{
+		"format.type":       "structured",
+		"format.options.id": "int",
+		"operations":        "create",
+	}
+
+	t.Run("probability", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["duplicates.probability"] = "1"
+		underTest := openTestSource(t, cfg)
+
+		rec1, err := underTest.Read(ctx)
+		is.NoErr(err)
+		dup, err := underTest.Read(ctx)
+		is.NoErr(err)
+
+		is.Equal(dup.Metadata[internal.MetadataDuplicate], "true")
+		is.Equal(dup.Key, rec1.Key)
+		is.Equal(dup.Payload, rec1.Payload)
+		is.True(!bytes.Equal(dup.Position, rec1.Position))
+
+		p, err := internal.ParsePosition(dup.Position)
+		is.NoErr(err)
+		is.Equal(p.Redelivered, 1)
+	})
+
+	t.Run("delay and same position", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["duplicates.probability"] = "1"
+		cfg["duplicates.delay"] = "50ms"
+		cfg["duplicates.samePosition"] = "true"
+		clock := newFakeClock()
+		underTest := openTestSourceWithClock(t, cfg, clock)
+
+		rec1, err := underTest.Read(ctx)
+		is.NoErr(err)
+		rec2, err := underTest.Read(ctx)
+		is.NoErr(err)
+		is.Equal(rec2.Metadata[internal.MetadataDuplicate], "") // duplicate not due yet
+
+		clock.Advance(50 * time.Millisecond)
+		dup, err := underTest.Read(ctx)
+		is.NoErr(err)
+		is.Equal(dup.Metadata[internal.MetadataDuplicate], "true")
+		is.Equal(dup.Payload, rec1.Payload)
+		is.Equal(dup.Position, rec1.Position)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["duplicates.replay"] = "2"
+		underTest := openTestSource(t, cfg)
+
+		var recs []opencdc.Record
+		for range 3 {
+			rec, err := underTest.Read(ctx)
+			is.NoErr(err)
+			recs = append(recs, rec)
+		}
+
+		underTest = openTestSourceAt(t, cfg, recs[2].Position)
+		positions := map[string]bool{string(recs[2].Position): true}
+		for _, want := range recs[1:] {
+			got, err := underTest.Read(ctx)
+			is.NoErr(err)
+			is.Equal(got.Metadata[internal.MetadataDuplicate], "true")
+			is.Equal(got.Key, want.Key)
+			is.Equal(got.Payload.After.Bytes(), want.Payload.After.Bytes())
+			is.True(!positions[string(got.Position)]) // every replayed record has its own position
+			positions[string(got.Position)] = true
+		}
+
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+		is.Equal(rec.Metadata[internal.MetadataDuplicate], "")
+	})
+
+	t.Run("replay max size", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["duplicates.replay"] = "10"
+		cfg["duplicates.replayMaxSize"] = "1KB"
+		underTest := openTestSource(t, cfg)
+
+		var last opencdc.Record
+		for range 20 {
+			rec, err := underTest.Read(ctx)
+			is.NoErr(err)
+			last = rec
+		}
+
+		p, err := internal.ParsePosition(last.Position)
+		is.NoErr(err)
+		is.True(len(p.Recent) > 0)
+		is.True(len(p.Recent) < 10)
+		size := 0
+		for _, rec := range p.Recent {
+			size += len(rec.Bytes())
+		}
+		is.True(size <= 1024)
+	})
+}
+
+func TestSource_Read_Malformed(t *testing.T) {
+	ctx := context.Background()
+	cfg := map[string]string{
+		"collections.raw.format.type":                "raw",
+		"collections.raw.format.options.id":          "int",
+		"collections.raw.format.options.name":        "string",
+		"collections.raw.operations":                 "create",
+		"collections.structured.format.type":         "structured",
+		"collections.structured.format.options.id":   "int",
+		"collections.structured.format.options.name": "string",
+		"collections.structured.operations":          "create",
+	}
+
+	testCases := []struct {
+		kind  string
+		check func(is *is.I, data opencdc.Data)
+	}{{
+		kind: internal.MalformedTruncated,
+		check: func(is *is.I, data opencdc.Data) {
+			raw, ok := data.(opencdc.RawData)
+			is.True(ok)
+			is.True(len(raw) > 0)
+			is.True(!json.Valid(raw))
+		},
+	}, {
+		kind: internal.MalformedWrongTypes,
+		check: func(is *is.I, data opencdc.Data) {
+			var sd map[string]any
+			is.NoErr(json.Unmarshal(data.Bytes(), &sd))
+			_, ok := sd["id"].(string)
+			is.True(ok)
+			_, ok = sd["name"].(float64)
+			is.True(ok)
+		},
+	}, {
+		kind: internal.MalformedInvalidUTF8,
+		check: func(is *is.I, data opencdc.Data) {
+			if sd, ok := data.(opencdc.StructuredData); ok {
+				var invalid bool
+				for _, v := range sd {
+					if s, ok := v.(string); ok && !utf8.ValidString(s) {
+						invalid = true
+					}
+				}
+				is.True(invalid)
+				return
+			}
+			is.True(!utf8.Valid(data.Bytes()))
+		},
+	}, {
+		kind: internal.MalformedOversized,
+		check: func(is *is.I, data opencdc.Data) {
+			is.True(len(data.Bytes()) >= 1024)
+		},
+	}, {
+		kind: internal.MalformedEmpty,
+		check: func(is *is.I, data opencdc.Data) {
+			if sd, ok := data.(opencdc.StructuredData); ok {
+				is.Equal(len(sd), 0)
+				return
+			}
+			is.Equal(len(data.Bytes()), 0)
+		},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.kind, func(t *testing.T) {
+			is := is.New(t)
+			cfg := maps.Clone(cfg)
+			for _, collection := range []string{"raw", "structured"} {
+				cfg["collections."+collection+".malformed.probability"] = "1"
+				cfg["collections."+collection+".malformed.kinds"] = tc.kind
+				cfg["collections."+collection+".malformed.oversizedSize"] = "1KB"
+			}
+			underTest := openTestSource(t, cfg)
+
+			for range 20 {
+				rec, err := underTest.Read(ctx)
+				is.NoErr(err)
+				is.Equal(rec.Metadata[internal.MetadataMalformed], tc.kind)
+				tc.check(is, rec.Payload.After)
+			}
+		})
+	}
+
+	t.Run("avro", func(t *testing.T) {
+		is := is.New(t)
+		// read through the SDK middleware, which encodes payloads with the
+		// attached schema
+		underTest := NewSource()
+		err := sdk.Util.ParseConfig(ctx, map[string]string{
+			"format.type":           "avro",
+			"format.options.path":   "./testdata/user.avsc",
+			"malformed.probability": "1",
+		}, underTest.Config(), Connector.NewSpecification().SourceParams)
+		is.NoErr(err)
+		is.NoErr(underTest.Open(ctx, nil))
+		t.Cleanup(func() { _ = underTest.Teardown(ctx) })
+
+		for range 20 {
+			recs, err := underTest.ReadN(ctx, 1)
+			is.NoErr(err)
+			is.True(recs[0].Metadata[internal.MetadataMalformed] != "")
+		}
+	})
+
+	t.Run("default kinds", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		for _, collection := range []string{"raw", "structured"} {
+			cfg["collections."+collection+".malformed.probability"] = "1"
+		}
+		underTest := openTestSource(t, cfg)
+
+		for range 100 {
+			rec, err := underTest.Read(ctx)
+			is.NoErr(err)
+			kind := rec.Metadata[internal.MetadataMalformed]
+			is.True(slices.Contains(internal.DefaultMalformedKinds, kind))
+			is.True(kind != internal.MalformedOversized)
+		}
+	})
+}
+
+func TestSource_Read_EventTime(t *testing.T) {
+	ctx := context.Background()
+	cfg := map[string]string{
+		"format.type":       "structured",
+		"format.options.ts": "time",
+		"operations":        "create",
+	}
+
+	read := func(is *is.I, underTest sdk.Source) (time.Time, time.Time) {
+		is.Helper()
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+		createdAt, err := rec.Metadata.GetCreatedAt()
+		is.NoErr(err)
+		ts := rec.Payload.After.(opencdc.StructuredData)["ts"].(time.Time)
+		is.True(ts.Equal(createdAt)) // time fields contain the event time
+		return createdAt, time.Now()
+	}
+
+	t.Run("monotonic with skew", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["eventTime.skew"] = "-1h"
+		underTest := openTestSource(t, cfg)
+
+		var last time.Time
+		for range 100 {
+			eventTime, now := read(is, underTest)
+			is.True(!eventTime.Before(last))
+			is.True(eventTime.Before(now.Add(-59 * time.Minute)))
+			last = eventTime
+		}
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["eventTime.model"] = "bounded"
+		cfg["eventTime.maxOutOfOrderness"] = "10s"
+		underTest := openTestSource(t, cfg)
+
+		var last time.Time
+		var outOfOrder int
+		for range 100 {
+			eventTime, now := read(is, underTest)
+			is.True(!eventTime.After(now))
+			is.True(eventTime.After(now.Add(-11 * time.Second)))
+			if eventTime.Before(last) {
+				outOfOrder++
+			}
+			last = eventTime
+		}
+		is.True(outOfOrder > 0)
+	})
+
+	t.Run("late events", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["eventTime.lateRatio"] = "0.5"
+		cfg["eventTime.lateness"] = "uniform(1m,2m)"
+		underTest := openTestSource(t, cfg)
+
+		var late int
+		for range 100 {
+			eventTime, now := read(is, underTest)
+			if lag := now.Sub(eventTime); lag >= time.Minute {
+				is.True(lag < 2*time.Minute+time.Second)
+				late++
+			}
+		}
+		is.True(late > 30 && late < 70)
+	})
+}
+
+func TestSource_Read_Clock(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := map[string]string{
+		"format.type":       "structured",
+		"format.options.ts": "time",
+		"operations":        "create",
+		"clock.start":       start.Format(time.RFC3339),
+	}
+
+	t.Run("step", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["clock.step"] = "1h"
+		underTest := openTestSource(t, cfg)
+
+		var last opencdc.Record
+		for i := range 3 {
+			rec, err := underTest.Read(ctx)
+			is.NoErr(err)
+			createdAt, err := rec.Metadata.GetCreatedAt()
+			is.NoErr(err)
+			want := start.Add(time.Duration(i) * time.Hour)
+			is.True(createdAt.Equal(want))
+			is.True(rec.Payload.After.(opencdc.StructuredData)["ts"].(time.Time).Equal(want))
+			last = rec
+		}
+
+		// the clock continues after a restart
+		underTest = openTestSourceAt(t, cfg, last.Position)
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+		createdAt, err := rec.Metadata.GetCreatedAt()
+		is.NoErr(err)
+		is.True(createdAt.Equal(start.Add(3 * time.Hour)))
+	})
+
+	t.Run("speed", func(t *testing.T) {
+		is := is.New(t)
+		cfg := maps.Clone(cfg)
+		cfg["clock.speed"] = "3600"
+		underTest := openTestSource(t, cfg)
+
+		time.Sleep(20 * time.Millisecond)
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+		createdAt, err := rec.Metadata.GetCreatedAt()
+		is.NoErr(err)
+		elapsed := createdAt.Sub(start)
+		is.True(elapsed >= 72*time.Second) // 20ms * 3600
+		is.True(elapsed < 10*time.Minute)
+	})
+}
+
+func TestSource_Read_Seed(t *testing.T) {
+	ctx := context.Background()
+	cfg := map[string]string{
+		"collections.users.format.type":                 "structured",
+		"collections.users.format.options.id":           "int",
+		"collections.users.format.options.name":         "string(cardinality=5)",
+		"collections.users.format.options.ts":           "time",
+		"collections.users.operations":                  "create,update,delete",
+		"collections.users.eventTime.model":             "bounded",
+		"collections.users.eventTime.maxOutOfOrderness": "1m",
+		"collections.users.malformed.probability":       "0.2",
+		"collections.events.format.type":                "raw",
+		"collections.events.format.options.id":          "int",
+		"collections.events.format.options.score":       "float",
+		"collections.shop.format.type":                  "sql",
+		"collections.shop.format.options.path":          "./testdata/shop.sql",
+		"duplicates.probability":                        "0.1",
+		"clock.start":                                   "2024-01-01T00:00:00Z",
+		"clock.step":                                    "1s",
+		"seed":                                          "42",
+	}
+
+	read := func(is *is.I, cfg map[string]string) []opencdc.Record {
+		is.Helper()
+		underTest := openTestSource(t, cfg)
+		recs := make([]opencdc.Record, 100)
+		for i := range recs {
+			rec, err := underTest.Read(ctx)
+			is.NoErr(err)
+			recs[i] = rec
+		}
+		return recs
+	}
+
+	t.Run("same seed", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(read(is, cfg), read(is, cfg))
+	})
+
+	t.Run("different seed", func(t *testing.T) {
+		is := is.New(t)
+		other := maps.Clone(cfg)
+		other["seed"] = "43"
+		is.True(!reflect.DeepEqual(read(is, cfg), read(is, other)))
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		is := is.New(t)
+		recs := read(is, cfg)
+
+		var restarted [2]opencdc.Record
+		for i := range restarted {
+			underTest := openTestSourceAt(t, cfg, recs[len(recs)-1].Position)
+			rec, err := underTest.Read(ctx)
+			is.NoErr(err)
+			restarted[i] = rec
+		}
+		is.Equal(restarted[0], restarted[1])
+	})
+}
+
+func TestSource_Read_MaxInFlight(t *testing.T) {
+	ctx := context.Background()
+	is := is.New(t)
+	underTest := openTestSource(t, map[string]string{
+		"maxInFlight":       "2",
+		"format.type":       "raw",
+		"format.options.id": "int",
+	})
+
+	var recs []opencdc.Record
+	for range 2 {
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+		recs = append(recs, rec)
+	}
+
+	// the third record is blocked until a record is acknowledged
+	readCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err := underTest.Read(readCtx)
+	is.Equal(err, context.DeadlineExceeded)
+
+	is.NoErr(underTest.Ack(ctx, recs[0].Position))
+	_, err = underTest.Read(ctx)
+	is.NoErr(err)
+}
+
+func TestSource_ReadN(t *testing.T) {
+	ctx := context.Background()
+	is := is.New(t)
+	clock := newFakeClock()
+	underTest := openTestSourceWithClock(t, map[string]string{
+		"recordCount":       "25",
+		"endOfStream":       "error",
+		"rate":              "100",
+		"format.type":       "raw",
+		"format.options.id": "int",
+	}, clock)
+
+	// the rate tokens of the whole batch are reserved at once
+	start := clock.Now()
+	recs, err := underTest.ReadN(ctx, 20)
+	is.NoErr(err)
+	is.Equal(len(recs), 20)
+	is.True(isAbout(clock.Now().Sub(start), 190*time.Millisecond))
+
+	// the batch is cut short by the record count
+	recs2, err := underTest.ReadN(ctx, 20)
+	is.NoErr(err)
+	is.Equal(len(recs2), 5)
+
+	positions := make(map[string]bool)
+	for _, rec := range append(recs, recs2...) {
+		positions[string(rec.Position)] = true
+	}
+	is.Equal(len(positions), 25)
+
+	_, err = underTest.ReadN(ctx, 20)
+	is.Equal(err, ErrEndOfStream)
+}
+
+func TestSource_ReadN_Canceled(t *testing.T) {
+	ctx := context.Background()
+	is := is.New(t)
+	clock := newFakeClock()
+	underTest := openTestSourceWithClock(t, map[string]string{
+		"rate":              "100",
+		"format.type":       "raw",
+		"format.options.id": "int",
+	}, clock)
+
+	recs, err := underTest.ReadN(ctx, 5)
+	is.NoErr(err)
+
+	// the read times out while waiting for the rate limit
+	clock.Stall(true)
+	readCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = underTest.ReadN(readCtx, 5)
+	is.Equal(err, context.DeadlineExceeded)
+	clock.Stall(false)
+
+	// the records prepared for the canceled read are not lost
+	more, err := underTest.ReadN(ctx, 5)
+	is.NoErr(err)
+	var p internal.Position
+	for i, rec := range append(recs, more...) {
+		p, err = internal.ParsePosition(rec.Position)
+		is.NoErr(err)
+		is.Equal(p.Seq, int64(i+1))
+		is.Equal(p.RecordCount, i+1)
+	}
+	is.Equal(p.Collections[""].Count, 10) // no generated record was skipped
+}
+
+func TestSource_ReadN_MaxInFlight(t *testing.T) {
+	ctx := context.Background()
+	is := is.New(t)
+	underTest := openTestSource(t, map[string]string{
+		"maxInFlight":       "3",
+		"format.type":       "raw",
+		"format.options.id": "int",
+	})
+
+	recs, err := underTest.ReadN(ctx, 10)
+	is.NoErr(err)
+	is.Equal(len(recs), 3)
+
+	// acknowledged records make room for the next batch
+	is.NoErr(underTest.Ack(ctx, recs[0].Position))
+	is.NoErr(underTest.Ack(ctx, recs[1].Position))
+	recs, err = underTest.ReadN(ctx, 10)
+	is.NoErr(err)
+	is.Equal(len(recs), 2)
+}
+
+func TestSource_Read_Pool(t *testing.T) {
+	ctx := context.Background()
+	is := is.New(t)
+	underTest := openTestSource(t, map[string]string{
+		"mode":                  "pool",
+		"pool.size":             "3",
+		"format.type":           "structured",
+		"format.options.id":     "int",
+		"format.options.name":   "string",
+		"format.options.active": "bool",
+	})
+
+	var recs []opencdc.Record
+	for range 7 {
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+		recs = append(recs, rec)
+	}
+
+	// the pooled records are cycled through with fresh positions
+	positions := make(map[string]bool)
+	for i, rec := range recs {
+		positions[string(rec.Position)] = true
+		if i >= 3 {
+			is.Equal(rec.Payload, recs[i-3].Payload)
+		}
+	}
+	is.Equal(len(positions), len(recs))
+
+	// the metadata and structured payloads are not shared between records
+	recs[0].Metadata["foo"] = "bar"
+	_, ok := recs[3].Metadata["foo"]
+	is.True(!ok)
+	recs[0].Payload.After.(opencdc.StructuredData)["foo"] = "bar"
+	_, ok = recs[3].Payload.After.(opencdc.StructuredData)["foo"]
+	is.True(!ok)
+}
+
+func TestSource_Read_PoolRestart(t *testing.T) {
+	ctx := context.Background()
+	is := is.New(t)
+	cfg := map[string]string{
+		"format.type":         "structured",
+		"format.options.id":   "int",
+		"format.options.name": "string",
+		"operations":          "create",
+		"evolution.records":   "2",
+		"evolution.steps":     "add:email:string",
+	}
+
+	// a position after the schema evolved
+	var pos opencdc.Position
+	underTest := openTestSource(t, cfg)
+	for range 4 {
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+		pos = rec.Position
+	}
+	want, err := internal.ParsePosition(pos)
+	is.NoErr(err)
+
+	cfg["mode"] = "pool"
+	cfg["pool.size"] = "3"
+	underTest = openTestSourceAt(t, cfg, pos)
+	for range 3 {
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+
+		// the pool is generated from the restored state
+		data := rec.Payload.After.(opencdc.StructuredData)
+		is.Equal(slices.Sorted(maps.Keys(data)), []string{"email", "id", "name"})
+
+		// filling the pool doesn't advance the state in the position
+		got, err := internal.ParsePosition(rec.Position)
+		is.NoErr(err)
+		is.Equal(got.Collections, want.Collections)
+	}
+}
+
+func TestSource_Ack_Verification(t *testing.T) {
+	ctx := context.Background()
+	is := is.New(t)
+	cfg := map[string]string{
+		"acks.failOnViolation": "true",
+		"format.type":          "raw",
+		"format.options.id":    "int",
+	}
+
+	s := &Source{}
+	err := sdk.Util.ParseConfig(ctx, cfg, s.Config(), Connector.NewSpecification().SourceParams)
+	is.NoErr(err)
+	is.NoErr(s.Open(ctx, nil))
+
+	var recs []opencdc.Record
+	for range 4 {
+		rec, err := s.Read(ctx)
+		is.NoErr(err)
+		recs = append(recs, rec)
+	}
+
+	is.NoErr(s.Ack(ctx, recs[0].Position))
+	is.NoErr(s.Ack(ctx, recs[2].Position))
+
+	err = s.Ack(ctx, recs[1].Position)
+	is.True(errors.Is(err, ErrAckViolation))
+	is.True(strings.Contains(err.Error(), internal.AckOutOfOrder))
+
+	err = s.Ack(ctx, recs[0].Position)
+	is.True(errors.Is(err, ErrAckViolation))
+	is.True(strings.Contains(err.Error(), internal.AckDuplicate))
+
+	err = s.Ack(ctx, opencdc.Position("foo"))
+	is.True(errors.Is(err, ErrAckViolation))
+	is.True(strings.Contains(err.Error(), internal.AckUnknown))
+
+	is.Equal(s.acks.Stats(), internal.AckStats{
+		Emitted:    4,
+		Acked:      3,
+		Unknown:    1,
+		Duplicate:  1,
+		OutOfOrder: 1,
+		Missing:    1,
+	})
+	err = s.Teardown(ctx)
+	is.True(errors.Is(err, ErrAckViolation))
+}
+
+func TestSource_Ack_Latency(t *testing.T) {
+	ctx := context.Background()
+	is := is.New(t)
+	clock := newFakeClock()
+	underTest := openTestSourceWithClock(t, map[string]string{
+		"format.type":       "raw",
+		"format.options.id": "int",
+	}, clock)
+	s := underTest.(*Source)
+
+	var recs []opencdc.Record
+	for range 10 {
+		rec, err := underTest.Read(ctx)
+		is.NoErr(err)
+		recs = append(recs, rec)
+	}
+	clock.Advance(20 * time.Millisecond)
+	for _, rec := range recs {
+		is.NoErr(underTest.Ack(ctx, rec.Position))
+	}
+
+	latency := s.acks.TotalLatency()
+	is.Equal(latency.Count, int64(10))
+	// the latencies are measured with the clock of the source
+	is.Equal(latency.P50, 20*time.Millisecond)
+	is.Equal(latency.P99, 20*time.Millisecond)
+	is.Equal(latency.Max, 20*time.Millisecond)
+
+	is.Equal(s.acks.IntervalLatency().Count, int64(10))
+	is.Equal(s.acks.IntervalLatency().Count, int64(0)) // reset after each interval
+}
+
+func testSourceRateLimit(t *testing.T, cfg map[string]string) {
+	ctx := context.Background()
+
+	underTest := openTestSource(t, cfg)
+
+	const epsilon = time.Millisecond * 10
+	readAssertDelay := func(is *is.I, expectedDelay time.Duration) {
+		is.Helper()
+		start := time.Now()
+		_, err := underTest.Read(ctx)
+		dur := time.Since(start)
+		is.NoErr(err)
+		is.True(dur >= expectedDelay-epsilon) // expected longer delay
+		is.True(dur <= expectedDelay+epsilon) // expected shorter delay
+	}
+
+	is := is.New(t)
+
+	// We start in the generate cycle, we can test the rate limiting here.
+	// The first record should be read immediately.
+	readAssertDelay(is, 0)
+
+	// The second record should already be rate limited and delayed by 50ms.
+	readAssertDelay(is, 50*time.Millisecond)
+
+	// If we wait for 50ms before reading, the next record should be read immediately.
+	time.Sleep(50 * time.Millisecond)
+	readAssertDelay(is, 0)
+
+	// If we wait for 25ms, the next record should be read after 25ms.
+	time.Sleep(25 * time.Millisecond)
+	readAssertDelay(is, 25*time.Millisecond)
+
+	// By now we should have reached the end of burst.generateTime (150ms).
+	// If we try to read a record now we should have to wait for 100ms (burst.sleepTime).
+	readAssertDelay(is, 100*time.Millisecond)
+
+	// After the sleep cycle we are again in the generate cycle. Reading a record
+	// should have the normal delay of 50ms.
+	readAssertDelay(is, 50*time.Millisecond)
+
+	// Wait for 100ms (remaining generate time) + 50ms (half of sleep time) = 150ms,
+	// so we are in the middle of the sleep cycle. Reading at that point should
+	// take 50ms.
+	time.Sleep(150 * time.Millisecond)
+	readAssertDelay(is, 50*time.Millisecond)
+}
+
+// testSourceRateLimitExact runs the steps of testSourceRateLimit on a fake
+// clock, so the delays are exactly the computed ones.
+func testSourceRateLimitExact(t *testing.T, cfg map[string]string) {
+	ctx := context.Background()
+
+	clock := newFakeClock()
+	underTest := openTestSourceWithClock(t, cfg, clock)
+
+	readAssertDelay := func(is *is.I, expectedDelay time.Duration) {
+		is.Helper()
+		start := clock.Now()
+		_, err := underTest.Read(ctx)
+		dur := clock.Now().Sub(start)
+		is.NoErr(err)
+		is.True(isAbout(dur, expectedDelay))
+	}
+
+	is := is.New(t)
+
+	// We start in the generate cycle, we can test the rate limiting here.
+	// The first record should be read immediately.
+	readAssertDelay(is, 0)
+
+	// The second record should already be rate limited and delayed by 50ms.
+	readAssertDelay(is, 50*time.Millisecond)
+
+	// If we wait for 50ms before reading, the next record should be read immediately.
+	clock.Advance(50 * time.Millisecond)
+	readAssertDelay(is, 0)
+
+	// If we wait for 25ms, the next record should be read after 25ms.
+	clock.Advance(25 * time.Millisecond)
+	readAssertDelay(is, 25*time.Millisecond)
+
+	// By now we should have reached the end of burst.generateTime (150ms).
+	// If we try to read a record now we should have to wait for 100ms (burst.sleepTime).
+	readAssertDelay(is, 100*time.Millisecond)
+
+	// After the sleep cycle we are again in the generate cycle. Reading a record
+	// should have the normal delay of 50ms.
+	readAssertDelay(is, 50*time.Millisecond)
+
+	// Wait for 100ms (remaining generate time) + 50ms (half of sleep time) = 150ms,
+	// so we are in the middle of the sleep cycle. Reading at that point should
+	// take 50ms.
+	clock.Advance(150 * time.Millisecond)
+	readAssertDelay(is, 50*time.Millisecond)
+}
+
+func BenchmarkSource_Read(b *testing.B) {
+	formats := map[string]map[string]string{
+		"raw": {
+			"format.type":         "raw",
+			"format.options.id":   "int",
+			"format.options.name": "string",
+			"format.options.time": "time",
+		},
+		"structured": {
+			"format.type":         "structured",
+			"format.options.id":   "int",
+			"format.options.name": "string",
+			"format.options.time": "time",
+		},
+	}
+	for _, format := range []string{"raw", "structured"} {
+		for _, mode := range []string{ModeGenerate, ModePool} {
+			b.Run(format+"/"+mode, func(b *testing.B) {
+				cfg := maps.Clone(formats[format])
+				cfg["mode"] = mode
+				underTest := openTestSource(b, cfg)
+				ctx := context.Background()
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for range b.N {
+					_, err := underTest.Read(ctx)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func openTestSource(t testing.TB, cfgMap map[string]string) sdk.Source {
+	return openTestSourceAt(t, cfgMap, nil)
+}
+
+func openTestSourceAt(t testing.TB, cfgMap map[string]string, pos opencdc.Position) sdk.Source {
+	return openTestSourceWith(t, cfgMap, pos, nil)
+}
+
+// openTestSourceWithClock opens a source that is paced by the given clock
+// instead of the wall clock.
+func openTestSourceWithClock(t testing.TB, cfgMap map[string]string, clock internal.Clock) sdk.Source {
+	return openTestSourceWith(t, cfgMap, nil, clock)
+}
+
+func openTestSourceWith(t testing.TB, cfgMap map[string]string, pos opencdc.Position, clock internal.Clock) sdk.Source {
+	is := is.New(t)
+	ctx := context.Background()
+
+	s := &Source{wallClock: clock}
+	t.Cleanup(func() {
+		_ = s.Teardown(ctx)
+	})
+
+	err := sdk.Util.ParseConfig(ctx, cfgMap, s.Config(), Connector.NewSpecification().SourceParams)
+	is.NoErr(err)
+
+	err = s.Open(ctx, pos)
+	is.NoErr(err)
+
+	return s
+}
+
+// fakeClock is a clock that only moves when Advance is called or when a
+// timer is waited for, in which case it jumps to the end of the timer. The
+// time passed while reading records is thereby exactly the computed delay.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+	// stalled makes waits block until they are canceled.
+	stalled bool
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	stalled := c.stalled
+	c.mu.Unlock()
+	if stalled {
+		return nil
+	}
+	ch := make(chan time.Time, 1)
+	ch <- c.Advance(d)
+	return ch
+}
+
+// Stall makes waits block until they are canceled, or ends it.
+func (c *fakeClock) Stall(stalled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stalled = stalled
+}
+
+// Advance moves the clock forward by d and returns the new time.
+func (c *fakeClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(max(d, 0))
+	return c.now
+}
+
+// isAbout reports whether d is within a microsecond of want, which allows
+// for rounding in the rate limiter.
+func isAbout(d, want time.Duration) bool {
+	return d >= want-time.Microsecond && d <= want+time.Microsecond
 }
@@ -0,0 +1,19 @@
+-- Tables mirroring a small shop database.
+CREATE TABLE IF NOT EXISTS public.customers (
+    id          BIGSERIAL PRIMARY KEY,
+    email       VARCHAR(5) NOT NULL UNIQUE,
+    age         INTEGER CHECK (age >= 18 AND age < 100),
+    tier        TEXT NOT NULL CHECK (tier IN ('free', 'pro')),
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+/* orders reference customers */
+CREATE TABLE orders (
+    id          INT NOT NULL,
+    customer_id BIGINT NOT NULL REFERENCES customers (id) ON DELETE CASCADE,
+    amount      NUMERIC(8, 2) NOT NULL,
+    quantity    SMALLINT NOT NULL,
+    shipped     BOOLEAN,
+    CONSTRAINT orders_pk PRIMARY KEY (id),
+    CONSTRAINT quantity_positive CHECK (quantity BETWEEN 1 AND 10)
+);